@@ -8,7 +8,6 @@ import (
 	"github.com/theskyinflames/cqrs-eda/pkg/bus"
 	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
 	"github.com/theskyinflames/cqrs-eda/pkg/events"
-	"github.com/theskyinflames/cqrs-eda/pkg/helpers"
 
 	"github.com/google/uuid"
 )
@@ -29,19 +28,15 @@ func (ac AddUserCommand) Name() string {
 // AddUserCommandHandler is a command handler
 type AddUserCommandHandler struct{}
 
-// Handle implements cqrs.CommandHandler interface
-func (ch AddUserCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) ([]events.Event, error) {
-	addUserCmd, ok := cmd.(AddUserCommand)
-	if !ok {
-		return nil, fmt.Errorf("expected command %s, but received %s", addUserCommandName, cmd.Name())
-	}
-	fmt.Printf("added user: %s (%s)\n", addUserCmd.UserName, addUserCmd.ID)
+// Handle implements cqrs.TypedCommandHandler interface
+func (ch AddUserCommandHandler) Handle(ctx context.Context, cmd AddUserCommand) ([]events.Event, error) {
+	fmt.Printf("added user: %s (%s)\n", cmd.UserName, cmd.ID)
 	return nil, nil
 }
 
 func main() {
-	bus := bus.New()
-	bus.Register(addUserCommandName, helpers.BusChHandler(AddUserCommandHandler{}))
+	commandBus := cqrs.NewCommandBus[AddUserCommand](bus.New())
+	commandBus.Register(addUserCommandName, AddUserCommandHandler{})
 
 	cmd := AddUserCommand{
 		ID:       uuid.New(),
@@ -50,7 +45,7 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	bus.Dispatch(ctx, cmd)
+	commandBus.Dispatch(ctx, cmd)
 
 	// Give time to output traces
 	time.Sleep(time.Second)
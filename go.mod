@@ -3,6 +3,7 @@ module github.com/theskyinflames/cqrs-eda
 go 1.19
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/google/uuid v1.3.0
 	github.com/stretchr/testify v1.8.1
 )
@@ -17,19 +17,38 @@ type Handler func(ctx context.Context, d Dispatchable) (interface{}, error)
 
 // Bus is self-described
 type Bus struct {
-	h map[string]Handler
+	h   registry[Handler]
+	mws *chain
 }
 
 // New is a constructor
 func New() Bus {
 	return Bus{
-		h: make(map[string]Handler),
+		h:   newRegistry[Handler](),
+		mws: newChain(),
 	}
 }
 
-// Register adds a new handler to the bus
-func (b Bus) Register(n string, h Handler) {
-	b.h[n] = h
+// Register adds a new handler to the bus, wrapped by the given middlewares.
+// It's safe to call it while the bus is dispatching.
+func (b Bus) Register(n string, h Handler, mws ...Middleware) {
+	b.h.set(n, MultiMiddleware(mws...)(h))
+}
+
+// Use adds middlewares that wrap all the handlers of the bus, including the ones already registered.
+// They are applied around the per-name middlewares given at registration.
+func (b Bus) Use(mws ...Middleware) {
+	b.mws.use(mws...)
+}
+
+// Unregister removes the handler for the given name from the bus
+func (b Bus) Unregister(n string) {
+	b.h.remove(n)
+}
+
+// Handlers returns the names with a registered handler, sorted
+func (b Bus) Handlers() []string {
+	return b.h.names()
 }
 
 // ErrNotDispatchable is self-described
@@ -37,9 +56,9 @@ var ErrNotDispatchable = errors.New("not dispatchable")
 
 // Dispatch dispatches a dispatchable item
 func (b Bus) Dispatch(ctx context.Context, d Dispatchable) (interface{}, error) {
-	h, ok := b.h[d.Name()]
+	h, ok := b.h.get(d.Name())
 	if !ok {
 		return nil, ErrNotDispatchable
 	}
-	return h(ctx, d)
+	return b.mws.wrap(h)(ctx, d)
 }
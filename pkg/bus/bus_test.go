@@ -3,6 +3,8 @@ package bus_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/theskyinflames/cqrs-eda/pkg/bus"
@@ -71,3 +73,50 @@ func TestBusDispatch(t *testing.T) {
 		require.Equal(t, tt.expected, response)
 	}
 }
+
+func TestBusRegistration(t *testing.T) {
+	d := &DispatchableMock{
+		NameFunc: func() string {
+			return "h"
+		},
+	}
+
+	t.Run(`Given a bus with registered handlers,
+		when one of them is unregistered,
+		then it's no longer dispatched nor listed`, func(t *testing.T) {
+		b := bus.New()
+		b.Register("h", handlerFixture(nil, nil))
+		b.Register("a", handlerFixture(nil, nil))
+		require.Equal(t, []string{"a", "h"}, b.Handlers())
+
+		b.Unregister("h")
+		require.Equal(t, []string{"a"}, b.Handlers())
+		_, err := b.Dispatch(context.Background(), d)
+		require.ErrorIs(t, err, bus.ErrNotDispatchable)
+	})
+
+	t.Run(`Given a bus that is dispatching,
+		when handlers are registered and unregistered at the same time,
+		then there is no data race`, func(t *testing.T) {
+		b := bus.New()
+		b.Register("h", handlerFixture(nil, nil))
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				b.Dispatch(context.Background(), d)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				b.Register(fmt.Sprintf("h%d", i), handlerFixture(nil, nil))
+				b.Unregister(fmt.Sprintf("h%d", i))
+			}
+		}()
+		wg.Wait()
+		require.Equal(t, []string{"h"}, b.Handlers())
+	})
+}
@@ -2,6 +2,7 @@ package bus
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -11,28 +12,75 @@ type Response struct {
 }
 
 type dispatchableWithContext struct {
-	ctx context.Context
-	d   Dispatchable
+	ctx  context.Context
+	d    Dispatchable
+	item *inFlight
+}
+
+// ErrBusFull is returned when a dispatchable can't be queued because the bus queue is full
+var ErrBusFull = errors.New("bus full")
+
+// ErrDropped is returned when a dispatchable is discarded by the overflow policy of the bus
+var ErrDropped = errors.New("dispatchable dropped")
+
+// OverflowPolicy defines what Dispatch does when the bus queue is full
+type OverflowPolicy int
+
+const (
+	// Block waits until there is room in the queue
+	Block OverflowPolicy = iota
+	// DropNewest discards the dispatchable being dispatched, which gets an ErrDropped error
+	DropNewest
+	// DropOldest discards the oldest queued dispatchable, which gets an ErrDropped error, to make room.
+	// With an unbuffered queue, it behaves like Block.
+	DropOldest
+	// Reject fails the dispatchable being dispatched with an ErrBusFull error
+	Reject
+)
+
+// ConcurrentBusOpt is an option for the concurrent bus constructor
+type ConcurrentBusOpt func(*ConcurrentBus)
+
+// WithQueueSize sets the size of the bus queue. By default, it's unbuffered.
+func WithQueueSize(size int) ConcurrentBusOpt {
+	return func(b *ConcurrentBus) {
+		b.in = make(chan dispatchableWithContext, size)
+	}
+}
 
-	rsChan chan Response
+// WithOverflowPolicy sets the policy Dispatch applies when the bus queue is full. By default, it's Block.
+func WithOverflowPolicy(p OverflowPolicy) ConcurrentBusOpt {
+	return func(b *ConcurrentBus) {
+		b.overflow = p
+	}
 }
 
 // ConcurrentBus dispatches concurrently
 type ConcurrentBus struct {
-	h        map[string]Handler
+	h        registry[Handler]
+	mws      *chain
 	timeout  time.Duration
 	poolSize chan struct{}
 	in       chan dispatchableWithContext
+	overflow OverflowPolicy
+	lc       *lifecycle
 }
 
 // NewConcurrentBus is a constructor
-func NewConcurrentBus(timeout time.Duration, concurrencyLimit int) ConcurrentBus {
-	return ConcurrentBus{
-		h:        make(map[string]Handler),
+func NewConcurrentBus(timeout time.Duration, concurrencyLimit int, opts ...ConcurrentBusOpt) ConcurrentBus {
+	b := ConcurrentBus{
+		h:        newRegistry[Handler](),
+		mws:      newChain(),
 		timeout:  timeout,
 		poolSize: make(chan struct{}, concurrencyLimit),
 		in:       make(chan dispatchableWithContext),
+		overflow: Block,
+		lc:       newLifecycle(),
+	}
+	for _, opt := range opts {
+		opt(&b)
 	}
+	return b
 }
 
 // CurrentSize is a getter
@@ -40,50 +88,210 @@ func (b ConcurrentBus) CurrentSize() int {
 	return len(b.poolSize)
 }
 
-// Register adds a new handler to the bus
-func (b ConcurrentBus) Register(n string, h Handler) {
-	b.h[n] = h
+// QueueLen returns the number of dispatchables waiting in the bus queue
+func (b ConcurrentBus) QueueLen() int {
+	return len(b.in)
+}
+
+// Register adds a new handler to the bus, wrapped by the given middlewares.
+// It's safe to call it while the bus is running.
+func (b ConcurrentBus) Register(n string, h Handler, mws ...Middleware) {
+	b.h.set(n, MultiMiddleware(mws...)(h))
+}
+
+// Use adds middlewares that wrap all the handlers of the bus, including the ones already registered.
+// They are applied around the per-name middlewares given at registration.
+func (b ConcurrentBus) Use(mws ...Middleware) {
+	b.mws.use(mws...)
 }
 
-// Dispatch dispatches a new dispatchable item
+// Unregister removes the handler for the given name from the bus.
+// Dispatchables already picked up by the bus are still handled by the removed handler.
+func (b ConcurrentBus) Unregister(n string) {
+	b.h.remove(n)
+}
+
+// Handlers returns the names with a registered handler, sorted
+func (b ConcurrentBus) Handlers() []string {
+	return b.h.names()
+}
+
+// Dispatch dispatches a new dispatchable item. If the bus queue is full, the bus overflow policy is applied.
+// Once the bus has been shut down, the response is an ErrBusClosed error.
 func (b ConcurrentBus) Dispatch(ctx context.Context, d Dispatchable) <-chan Response {
-	rsChan := make(chan Response, 1)
-	b.in <- dispatchableWithContext{
-		ctx:    ctx,
-		d:      d,
-		rsChan: rsChan,
+	dwc, err := b.accept(ctx, d)
+	if err != nil {
+		return errResponse(err)
+	}
+
+	switch b.overflow {
+	case DropNewest:
+		if err = b.tryEnqueue(dwc); errors.Is(err, ErrBusFull) {
+			err = ErrDropped
+		}
+	case DropOldest:
+		err = b.enqueueDroppingOldest(dwc)
+	case Reject:
+		err = b.tryEnqueue(dwc)
+	default:
+		err = b.enqueue(dwc)
+	}
+	if err != nil {
+		b.lc.finish(dwc.item, Response{Err: err})
+	}
+	return dwc.item.rsChan
+}
+
+// TryDispatch dispatches a new dispatchable item without blocking.
+// It fails fast with an ErrBusFull error if the bus queue is full, whatever the overflow policy is.
+func (b ConcurrentBus) TryDispatch(ctx context.Context, d Dispatchable) (<-chan Response, error) {
+	dwc, err := b.accept(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.tryEnqueue(dwc); err != nil {
+		b.lc.finish(dwc.item, Response{Err: err})
+		return nil, err
+	}
+	return dwc.item.rsChan, nil
+}
+
+// DispatchWait dispatches a new dispatchable item, waiting until there is room in the bus queue or ctx is done
+func (b ConcurrentBus) DispatchWait(ctx context.Context, d Dispatchable) (<-chan Response, error) {
+	dwc, err := b.accept(ctx, d)
+	if err != nil {
+		return nil, err
 	}
+	if err := b.enqueue(dwc); err != nil {
+		b.lc.finish(dwc.item, Response{Err: err})
+		return nil, err
+	}
+	return dwc.item.rsChan, nil
+}
+
+func errResponse(err error) <-chan Response {
+	rsChan := make(chan Response, 1)
+	rsChan <- Response{Err: err}
 	return rsChan
 }
 
-// Run start running the bus
+// accept starts tracking a dispatchable, unless the bus is closed
+func (b ConcurrentBus) accept(ctx context.Context, d Dispatchable) (dispatchableWithContext, error) {
+	itemCtx, cancel := context.WithCancel(ctx)
+	item, ok := b.lc.track(d, cancel)
+	if !ok {
+		cancel()
+		return dispatchableWithContext{}, ErrBusClosed
+	}
+	return dispatchableWithContext{
+		ctx:  itemCtx,
+		d:    d,
+		item: item,
+	}, nil
+}
+
+func (b ConcurrentBus) enqueue(dwc dispatchableWithContext) error {
+	select {
+	case b.in <- dwc:
+		return nil
+	case <-dwc.ctx.Done():
+		return dwc.ctx.Err()
+	}
+}
+
+func (b ConcurrentBus) tryEnqueue(dwc dispatchableWithContext) error {
+	select {
+	case b.in <- dwc:
+		return nil
+	default:
+		return ErrBusFull
+	}
+}
+
+func (b ConcurrentBus) enqueueDroppingOldest(dwc dispatchableWithContext) error {
+	if cap(b.in) == 0 {
+		return b.enqueue(dwc)
+	}
+	for {
+		if err := b.tryEnqueue(dwc); err == nil {
+			return nil
+		}
+		// The queue is full, so it waits for room or for an oldest item to drop, instead of spinning
+		select {
+		case b.in <- dwc:
+			return nil
+		case oldest := <-b.in:
+			b.lc.finish(oldest.item, Response{Err: ErrDropped})
+		case <-dwc.ctx.Done():
+			return dwc.ctx.Err()
+		}
+	}
+}
+
+// Run start running the bus. When ctx is cancelled, the bus is closed, the queued dispatchables
+// are responded with an ErrBusClosed error, and the in-flight handlers are abandoned.
+// Use Shutdown to stop it gracefully.
 func (b ConcurrentBus) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			b.lc.close()
+			b.lc.dropPending(ErrBusClosed)
+			return
+		case <-b.lc.drained:
 			return
 		case dwc := <-b.in:
-			h, ok := b.h[dwc.d.Name()]
-			if !ok {
-				dwc.rsChan <- Response{
-					Err: ErrNotDispatchable,
-				}
-				continue
-			}
-			b.poolSize <- struct{}{}
-
-			go func() {
-				withTimeoutCtx, cancel := context.WithTimeout(dwc.ctx, b.timeout)
-				defer cancel()
-				defer func() {
-					<-b.poolSize
-				}()
-				rs, err := h(withTimeoutCtx, dwc.d)
-				dwc.rsChan <- Response{
-					Response: rs,
-					Err:      err,
-				}
-			}()
+			b.handle(ctx, dwc)
 		}
 	}
 }
+
+func (b ConcurrentBus) handle(ctx context.Context, dwc dispatchableWithContext) {
+	h, ok := b.h.get(dwc.d.Name())
+	if !ok {
+		b.lc.finish(dwc.item, Response{
+			Err: ErrNotDispatchable,
+		})
+		return
+	}
+	h = b.mws.wrap(h)
+
+	select {
+	case b.poolSize <- struct{}{}:
+	case <-dwc.ctx.Done():
+		// Cancelled by the caller, or dropped, while waiting for a free slot
+		b.lc.finish(dwc.item, Response{
+			Err: dwc.ctx.Err(),
+		})
+		return
+	case <-ctx.Done():
+		b.lc.finish(dwc.item, Response{
+			Err: ErrBusClosed,
+		})
+		return
+	}
+	if !b.lc.start(dwc.item) {
+		<-b.poolSize
+		return
+	}
+
+	go func() {
+		withTimeoutCtx, cancel := context.WithTimeout(dwc.ctx, b.timeout)
+		defer cancel()
+		defer func() {
+			<-b.poolSize
+		}()
+		rs, err := h(withTimeoutCtx, dwc.d)
+		b.lc.finish(dwc.item, Response{
+			Response: rs,
+			Err:      err,
+		})
+	}()
+}
+
+// Shutdown stops the bus gracefully. It stops accepting new dispatchables, which get an ErrBusClosed error,
+// and waits for the queued and in-flight ones to finish. If ctx is done before that, they are cancelled
+// and returned as dropped together with the ctx error.
+func (b ConcurrentBus) Shutdown(ctx context.Context) ([]Dispatchable, error) {
+	return b.lc.shutdown(ctx)
+}
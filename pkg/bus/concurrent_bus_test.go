@@ -184,3 +184,256 @@ func TestConcurrentBus(t *testing.T) {
 		})
 	})
 }
+
+func TestConcurrentBusRegistration(t *testing.T) {
+	t.Run(`Given a running concurrent bus,
+		when handlers are registered and unregistered at runtime,
+		then dispatching follows the current handlers without data races`, func(t *testing.T) {
+		d := &DispatchableMock{
+			NameFunc: func() string {
+				return "h"
+			},
+		}
+		cbus := bus.NewConcurrentBus(time.Hour, 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cbus.Run(ctx)
+
+		rs := <-cbus.Dispatch(ctx, d)
+		require.ErrorIs(t, rs.Err, bus.ErrNotDispatchable)
+
+		cbus.Register("h", handlerFixture("a response", nil))
+		require.Equal(t, []string{"h"}, cbus.Handlers())
+		rs = <-cbus.Dispatch(ctx, d)
+		require.NoError(t, rs.Err)
+		require.Equal(t, "a response", rs.Response)
+
+		cbus.Unregister("h")
+		require.Empty(t, cbus.Handlers())
+		rs = <-cbus.Dispatch(ctx, d)
+		require.ErrorIs(t, rs.Err, bus.ErrNotDispatchable)
+	})
+}
+
+func TestConcurrentBusShutdown(t *testing.T) {
+	d := &DispatchableMock{
+		NameFunc: func() string {
+			return "h"
+		},
+	}
+
+	t.Run(`Given a running concurrent bus with an in-flight dispatchable,
+		when it's shut down,
+		then it waits for the handler to finish and new dispatchables are rejected`, func(t *testing.T) {
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+		cbus := bus.NewConcurrentBus(time.Hour, 1)
+		cbus.Register("h", func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			close(started)
+			<-release
+			return "a response", nil
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cbus.Run(ctx)
+
+		rsChan := cbus.Dispatch(ctx, d)
+		<-started
+
+		shutdownDone := make(chan struct{})
+		go func() {
+			defer close(shutdownDone)
+			dropped, err := cbus.Shutdown(context.Background())
+			require.NoError(t, err)
+			require.Empty(t, dropped)
+		}()
+
+		unknown := &DispatchableMock{}
+		require.Eventually(t, func() bool {
+			return errors.Is((<-cbus.Dispatch(ctx, unknown)).Err, bus.ErrBusClosed)
+		}, time.Second, time.Millisecond)
+
+		close(release)
+		<-shutdownDone
+		rs := <-rsChan
+		require.NoError(t, rs.Err)
+		require.Equal(t, "a response", rs.Response)
+	})
+
+	t.Run(`Given a running concurrent bus with a handler that doesn't finish in time,
+		when it's shut down with a deadline,
+		then the handler is cancelled and its dispatchable reported as dropped`, func(t *testing.T) {
+		started := make(chan struct{})
+		cbus := bus.NewConcurrentBus(time.Hour, 1)
+		cbus.Register("h", func(ctx context.Context, _ bus.Dispatchable) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cbus.Run(ctx)
+
+		rsChan := cbus.Dispatch(ctx, d)
+		<-started
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer shutdownCancel()
+		dropped, err := cbus.Shutdown(shutdownCtx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, []bus.Dispatchable{d}, dropped)
+		require.ErrorIs(t, (<-rsChan).Err, context.Canceled)
+	})
+
+	t.Run(`Given a concurrent bus whose Run context has been cancelled,
+		when a dispatchable is dispatched,
+		then it doesn't block and an ErrBusClosed is returned`, func(t *testing.T) {
+		cbus := bus.NewConcurrentBus(time.Hour, 1)
+		cbus.Register("h", handlerFixture(nil, nil))
+		ctx, cancel := context.WithCancel(context.Background())
+		runDone := make(chan struct{})
+		go func() {
+			defer close(runDone)
+			cbus.Run(ctx)
+		}()
+		cancel()
+		<-runDone
+
+		rs := <-cbus.Dispatch(context.Background(), d)
+		require.ErrorIs(t, rs.Err, bus.ErrBusClosed)
+	})
+}
+
+func TestConcurrentBusBackpressure(t *testing.T) {
+	dispatchable := func(n string) bus.Dispatchable {
+		return &DispatchableMock{
+			NameFunc: func() string {
+				return n
+			},
+		}
+	}
+
+	// blockedBus returns a running bus with a concurrency limit of 1 and a queue of 1,
+	// whose only slot is busy until release is closed
+	blockedBus := func(t *testing.T, opts ...bus.ConcurrentBusOpt) (bus.ConcurrentBus, chan struct{}) {
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+		cbus := bus.NewConcurrentBus(time.Hour, 1, append([]bus.ConcurrentBusOpt{bus.WithQueueSize(1)}, opts...)...)
+		cbus.Register("block", func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		cbus.Register("h", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			return d, nil
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go cbus.Run(ctx)
+
+		cbus.Dispatch(ctx, dispatchable("block"))
+		<-started
+		return cbus, release
+	}
+
+	// fill makes Run wait for a free slot with one dispatchable and queues another one
+	fill := func(t *testing.T, cbus bus.ConcurrentBus) (<-chan bus.Response, bus.Dispatchable) {
+		waiting := dispatchable("h")
+		cbus.Dispatch(context.Background(), waiting)
+		require.Eventually(t, func() bool { return cbus.QueueLen() == 0 }, time.Second, time.Millisecond)
+		queued := dispatchable("h")
+		rsChan := cbus.Dispatch(context.Background(), queued)
+		require.Equal(t, 1, cbus.QueueLen())
+		return rsChan, queued
+	}
+
+	t.Run(`Given a concurrent bus with a full queue,
+		when TryDispatch is called,
+		then it fails fast with an ErrBusFull error`, func(t *testing.T) {
+		cbus, release := blockedBus(t)
+		defer close(release)
+		fill(t, cbus)
+
+		_, err := cbus.TryDispatch(context.Background(), dispatchable("h"))
+		require.ErrorIs(t, err, bus.ErrBusFull)
+	})
+
+	t.Run(`Given a concurrent bus with a full queue,
+		when DispatchWait is called,
+		then it waits until ctx is done`, func(t *testing.T) {
+		cbus, release := blockedBus(t)
+		defer close(release)
+		fill(t, cbus)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := cbus.DispatchWait(ctx, dispatchable("h"))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run(`Given a concurrent bus with a full queue,
+		when DispatchWait is called and room is made,
+		then the dispatchable is handled`, func(t *testing.T) {
+		cbus, release := blockedBus(t)
+		fill(t, cbus)
+
+		d := dispatchable("h")
+		go close(release)
+		rsChan, err := cbus.DispatchWait(context.Background(), d)
+		require.NoError(t, err)
+		require.Equal(t, d, (<-rsChan).Response)
+	})
+
+	t.Run(`Given a concurrent bus with a full queue and a reject policy,
+		when Dispatch is called,
+		then an ErrBusFull error is responded`, func(t *testing.T) {
+		cbus, release := blockedBus(t, bus.WithOverflowPolicy(bus.Reject))
+		defer close(release)
+		fill(t, cbus)
+
+		rs := <-cbus.Dispatch(context.Background(), dispatchable("h"))
+		require.ErrorIs(t, rs.Err, bus.ErrBusFull)
+	})
+
+	t.Run(`Given a concurrent bus with a full queue and a drop-newest policy,
+		when Dispatch is called,
+		then the new dispatchable is dropped`, func(t *testing.T) {
+		cbus, release := blockedBus(t, bus.WithOverflowPolicy(bus.DropNewest))
+		fill(t, cbus)
+
+		rs := <-cbus.Dispatch(context.Background(), dispatchable("h"))
+		require.ErrorIs(t, rs.Err, bus.ErrDropped)
+		close(release)
+	})
+
+	t.Run(`Given a concurrent bus with a full queue and a drop-oldest policy,
+		when Dispatch is called,
+		then the oldest queued dispatchable is dropped and the new one handled`, func(t *testing.T) {
+		cbus, release := blockedBus(t, bus.WithOverflowPolicy(bus.DropOldest))
+		oldestRsChan, _ := fill(t, cbus)
+
+		d := dispatchable("h")
+		rsChan := cbus.Dispatch(context.Background(), d)
+		require.ErrorIs(t, (<-oldestRsChan).Err, bus.ErrDropped)
+
+		close(release)
+		require.Equal(t, d, (<-rsChan).Response)
+	})
+
+	t.Run(`Given a concurrent bus with queued dispatchables,
+		when it's shut down,
+		then they are drained before it finishes`, func(t *testing.T) {
+		cbus, release := blockedBus(t)
+		rsChan, queued := fill(t, cbus)
+
+		go close(release)
+		dropped, err := cbus.Shutdown(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, dropped)
+		require.Equal(t, queued, (<-rsChan).Response)
+	})
+}
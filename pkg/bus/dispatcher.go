@@ -0,0 +1,40 @@
+package bus
+
+import "context"
+
+// Dispatcher dispatches a dispatchable and waits for its response, like Bus does.
+// Use Await to dispatch to a ConcurrentBus or a PartitionedBus.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, d Dispatchable) (interface{}, error)
+}
+
+// Registrar is a bus where handlers can be registered, like Bus, ConcurrentBus, PartitionedBus and FanOutBus
+type Registrar interface {
+	Register(n string, h Handler, mws ...Middleware)
+}
+
+// DispatcherFunc is a function that dispatches a dispatchable and waits for its response, like Bus.Dispatch does
+type DispatcherFunc func(ctx context.Context, d Dispatchable) (interface{}, error)
+
+// Dispatch calls the function
+func (df DispatcherFunc) Dispatch(ctx context.Context, d Dispatchable) (interface{}, error) {
+	return df(ctx, d)
+}
+
+// asyncDispatcher is a bus that responds through a channel, like ConcurrentBus and PartitionedBus
+type asyncDispatcher interface {
+	Dispatch(ctx context.Context, d Dispatchable) <-chan Response
+}
+
+// Await adapts a ConcurrentBus or a PartitionedBus to dispatch waiting for the response, like Bus does.
+// If ctx is done before the response arrives, the ctx error is returned.
+func Await(b asyncDispatcher) DispatcherFunc {
+	return func(ctx context.Context, d Dispatchable) (interface{}, error) {
+		select {
+		case rs := <-b.Dispatch(ctx, d):
+			return rs.Response, rs.Err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAwait(t *testing.T) {
+	d := &DispatchableMock{
+		NameFunc: func() string {
+			return "h"
+		},
+	}
+	randomErr := errors.New("")
+
+	t.Run(`Given a running concurrent bus awaited, when a dispatchable is dispatched, then the handler response is returned`, func(t *testing.T) {
+		cbus := bus.NewConcurrentBus(time.Second, 1)
+		cbus.Register("h", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			return d, randomErr
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cbus.Run(ctx)
+
+		rs, err := bus.Await(cbus).Dispatch(context.Background(), d)
+		require.ErrorIs(t, err, randomErr)
+		require.Equal(t, d, rs)
+	})
+
+	t.Run(`Given a concurrent bus not running awaited, when the context is done before the response, then the context error is returned`, func(t *testing.T) {
+		cbus := bus.NewConcurrentBus(time.Second, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := bus.Await(cbus).Dispatch(ctx, d)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
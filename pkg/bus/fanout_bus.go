@@ -0,0 +1,120 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FanOutMode defines how a FanOutBus invokes the handlers of a dispatchable
+type FanOutMode int
+
+const (
+	// Sequential invokes the handlers one after the other, in registration order
+	Sequential FanOutMode = iota
+	// Parallel invokes all the handlers at the same time
+	Parallel
+)
+
+// Responses is the aggregated result of a fan-out dispatch.
+// It contains one Response for each handler, in registration order.
+type Responses []Response
+
+// Err returns the first handler error, if any
+func (rs Responses) Err() error {
+	for i, r := range rs {
+		if r.Err != nil {
+			return fmt.Errorf("handler %d: %w", i, r.Err)
+		}
+	}
+	return nil
+}
+
+// FanOutBus dispatches each dispatchable to all the handlers registered for its name.
+// It gives publish/subscribe semantics, so it's useful as an events bus.
+type FanOutBus struct {
+	h    registry[[]Handler]
+	mws  *chain
+	mode FanOutMode
+}
+
+// NewFanOutBus is a constructor
+func NewFanOutBus(mode FanOutMode) FanOutBus {
+	return FanOutBus{
+		h:    newRegistry[[]Handler](),
+		mws:  newChain(),
+		mode: mode,
+	}
+}
+
+// Register adds a new handler to the bus, wrapped by the given middlewares.
+// Previously registered handlers for the same name are kept.
+// It's safe to call it while the bus is dispatching.
+func (b FanOutBus) Register(n string, h Handler, mws ...Middleware) {
+	h = MultiMiddleware(mws...)(h)
+	b.h.update(n, func(hs []Handler) []Handler {
+		// Copy on write, so in-flight dispatches keep iterating over their own slice
+		return append(hs[:len(hs):len(hs)], h)
+	})
+}
+
+// Use adds middlewares that wrap all the handlers of the bus, including the ones already registered.
+// They are applied around the per-name middlewares given at registration.
+func (b FanOutBus) Use(mws ...Middleware) {
+	b.mws.use(mws...)
+}
+
+// Unregister removes all the handlers for the given name from the bus
+func (b FanOutBus) Unregister(n string) {
+	b.h.remove(n)
+}
+
+// Handlers returns the names with at least one registered handler, sorted
+func (b FanOutBus) Handlers() []string {
+	return b.h.names()
+}
+
+// Dispatch dispatches a dispatchable item to all its handlers.
+// The returned value is a Responses, and the error is the first handler error, if any.
+func (b FanOutBus) Dispatch(ctx context.Context, d Dispatchable) (interface{}, error) {
+	rs, err := b.Publish(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	return rs, rs.Err()
+}
+
+// Publish dispatches a dispatchable item to all its handlers and returns their aggregated responses
+func (b FanOutBus) Publish(ctx context.Context, d Dispatchable) (Responses, error) {
+	hs, ok := b.h.get(d.Name())
+	if !ok || len(hs) == 0 {
+		return nil, ErrNotDispatchable
+	}
+
+	rs := make(Responses, len(hs))
+	if b.mode == Sequential {
+		for i, h := range hs {
+			rs[i] = b.handle(ctx, h, d)
+		}
+		return rs, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(hs))
+	for i, h := range hs {
+		go func(i int, h Handler) {
+			defer wg.Done()
+			rs[i] = b.handle(ctx, h, d)
+		}(i, h)
+	}
+	wg.Wait()
+	return rs, nil
+}
+
+func (b FanOutBus) handle(ctx context.Context, h Handler, d Dispatchable) Response {
+	rs, err := b.mws.wrap(h)(ctx, d)
+	return Response{
+		Response: rs,
+		Err:      err,
+	}
+}
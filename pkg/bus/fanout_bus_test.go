@@ -0,0 +1,93 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOutBusDispatch(t *testing.T) {
+	var (
+		randomErr = errors.New("")
+		d         = &DispatchableMock{
+			NameFunc: func() string {
+				return "h"
+			},
+		}
+	)
+
+	for _, mode := range []bus.FanOutMode{bus.Sequential, bus.Parallel} {
+		t.Run(`Given a fan-out bus without handlers, when it's called, then an error is returned`, func(t *testing.T) {
+			b := bus.NewFanOutBus(mode)
+			_, err := b.Dispatch(context.Background(), d)
+			require.ErrorIs(t, err, bus.ErrNotDispatchable)
+		})
+
+		t.Run(`Given a fan-out bus with several handlers for the same name,
+			when it's called,
+			then all of them are invoked and their responses are aggregated in registration order`, func(t *testing.T) {
+			b := bus.NewFanOutBus(mode)
+			b.Register("h", randomTimeHandlerFixture("1", 20*time.Millisecond, nil))
+			b.Register("h", handlerFixture(nil, randomErr))
+			b.Register("h", handlerFixture("3", nil))
+
+			rs, err := b.Publish(context.Background(), d)
+			require.NoError(t, err)
+			require.Equal(t, bus.Responses{
+				{Response: "1"},
+				{Err: randomErr},
+				{Response: "3"},
+			}, rs)
+			require.ErrorIs(t, rs.Err(), randomErr)
+
+			got, err := b.Dispatch(context.Background(), d)
+			require.ErrorIs(t, err, randomErr)
+			require.Equal(t, rs, got)
+		})
+	}
+
+	t.Run(`Given a parallel fan-out bus,
+		when it's called,
+		then the handlers run at the same time`, func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			barrier = func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+				// Each handler only finishes once both have started
+				wg.Done()
+				wg.Wait()
+				return nil, nil
+			}
+		)
+		wg.Add(2)
+		b := bus.NewFanOutBus(bus.Parallel)
+		b.Register("h", barrier)
+		b.Register("h", barrier)
+
+		rs, err := b.Publish(context.Background(), d)
+		require.NoError(t, err)
+		require.Len(t, rs, 2)
+	})
+}
+
+func TestFanOutBusRegistration(t *testing.T) {
+	t.Run(`Given a fan-out bus with several handlers for a name,
+		when the name is unregistered,
+		then all its handlers are removed`, func(t *testing.T) {
+		b := bus.NewFanOutBus(bus.Sequential)
+		b.Register("h", handlerFixture(nil, nil))
+		b.Register("h", handlerFixture(nil, nil))
+		b.Register("a", handlerFixture(nil, nil))
+		require.Equal(t, []string{"a", "h"}, b.Handlers())
+
+		b.Unregister("h")
+		require.Equal(t, []string{"a"}, b.Handlers())
+		_, err := b.Publish(context.Background(), &DispatchableMock{NameFunc: func() string { return "h" }})
+		require.ErrorIs(t, err, bus.ErrNotDispatchable)
+	})
+}
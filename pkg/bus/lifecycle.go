@@ -0,0 +1,152 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBusClosed is returned when a dispatchable is dispatched to a bus that has been shut down
+var ErrBusClosed = errors.New("bus closed")
+
+// inFlight is a dispatchable accepted by the bus that has not been responded yet
+type inFlight struct {
+	d       Dispatchable
+	cancel  context.CancelFunc
+	rsChan  chan Response
+	once    *sync.Once
+	started bool
+}
+
+// respond sends the response of the dispatchable. Only the first response is sent.
+func (item *inFlight) respond(rs Response) {
+	item.once.Do(func() {
+		item.rsChan <- rs
+	})
+}
+
+// lifecycle tracks the in-flight dispatchables of a bus to allow a graceful shutdown
+type lifecycle struct {
+	mux      *sync.Mutex
+	closed   chan struct{}
+	drained  chan struct{}
+	isClosed bool
+	inFlight map[*inFlight]struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		mux:      &sync.Mutex{},
+		closed:   make(chan struct{}),
+		drained:  make(chan struct{}),
+		inFlight: make(map[*inFlight]struct{}),
+	}
+}
+
+// track registers a dispatchable as in-flight. It returns false if the bus is closed.
+func (l *lifecycle) track(d Dispatchable, cancel context.CancelFunc) (*inFlight, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.isClosed {
+		return nil, false
+	}
+	item := &inFlight{
+		d:      d,
+		cancel: cancel,
+		rsChan: make(chan Response, 1),
+		once:   &sync.Once{},
+	}
+	l.inFlight[item] = struct{}{}
+	return item, true
+}
+
+// start marks an in-flight dispatchable as being handled.
+// It returns false if it has already been finished, for instance, dropped by a shutdown.
+func (l *lifecycle) start(item *inFlight) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if _, ok := l.inFlight[item]; !ok {
+		return false
+	}
+	item.started = true
+	return true
+}
+
+// finish responds an in-flight dispatchable and stops tracking it
+func (l *lifecycle) finish(item *inFlight, rs Response) {
+	item.respond(rs)
+	item.cancel()
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.untrack(item)
+}
+
+func (l *lifecycle) untrack(item *inFlight) {
+	delete(l.inFlight, item)
+	if l.isClosed && len(l.inFlight) == 0 {
+		l.closeDrained()
+	}
+}
+
+// close stops accepting new dispatchables
+func (l *lifecycle) close() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.isClosed {
+		return
+	}
+	l.isClosed = true
+	close(l.closed)
+	if len(l.inFlight) == 0 {
+		l.closeDrained()
+	}
+}
+
+func (l *lifecycle) closeDrained() {
+	select {
+	case <-l.drained:
+	default:
+		close(l.drained)
+	}
+}
+
+// abort cancels all the in-flight dispatchables and returns them.
+// The ones not being handled yet are responded with err. The others will be responded by their handlers.
+func (l *lifecycle) abort(err error) []Dispatchable {
+	return l.drop(err, true)
+}
+
+// dropPending responds with err the in-flight dispatchables not being handled yet, and returns them
+func (l *lifecycle) dropPending(err error) []Dispatchable {
+	return l.drop(err, false)
+}
+
+func (l *lifecycle) drop(err error, all bool) []Dispatchable {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	var dropped []Dispatchable
+	for item := range l.inFlight {
+		if item.started && !all {
+			continue
+		}
+		item.cancel()
+		if !item.started {
+			item.respond(Response{Err: err})
+			l.untrack(item)
+		}
+		dropped = append(dropped, item.d)
+	}
+	return dropped
+}
+
+// shutdown closes the lifecycle and waits for the in-flight dispatchables to finish.
+// If ctx is done before, they are cancelled and returned as dropped.
+func (l *lifecycle) shutdown(ctx context.Context) ([]Dispatchable, error) {
+	l.close()
+	select {
+	case <-l.drained:
+		return nil, nil
+	case <-ctx.Done():
+		return l.abort(ctx.Err()), ctx.Err()
+	}
+}
@@ -0,0 +1,61 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Middleware wraps a handler to intercept the flux to and from it
+type Middleware func(Handler) Handler
+
+// MultiMiddleware chains several middlewares into one.
+// Like the cqrs handlers middlewares, they are applied in the given order, so the last one is the outermost.
+func MultiMiddleware(mws ...Middleware) Middleware {
+	return func(h Handler) Handler {
+		for _, mw := range mws {
+			h = mw(h)
+		}
+		return h
+	}
+}
+
+// ErrHandlerPanic is returned by the Recover middleware when the handler panics
+var ErrHandlerPanic = errors.New("handler panic")
+
+// Recover is a middleware that turns a handler panic into an ErrHandlerPanic error
+func Recover() Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, d Dispatchable) (rs interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					rs, err = nil, fmt.Errorf("%w: %s: %v", ErrHandlerPanic, d.Name(), r)
+				}
+			}()
+			return h(ctx, d)
+		}
+	}
+}
+
+// chain is a thread-safe list of global middlewares
+type chain struct {
+	mux *sync.RWMutex
+	mws []Middleware
+}
+
+func newChain() *chain {
+	return &chain{mux: &sync.RWMutex{}}
+}
+
+func (c *chain) use(mws ...Middleware) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.mws = append(c.mws, mws...)
+}
+
+func (c *chain) wrap(h Handler) Handler {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return MultiMiddleware(c.mws...)(h)
+}
@@ -0,0 +1,122 @@
+package bus_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testMw(name string, calls *[]string, mux *sync.Mutex) bus.Middleware {
+	return func(h bus.Handler) bus.Handler {
+		return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+			mux.Lock()
+			*calls = append(*calls, name)
+			mux.Unlock()
+			return h(ctx, d)
+		}
+	}
+}
+
+func TestMultiMiddleware(t *testing.T) {
+	t.Run(`Given a sequence of middlewares,
+		when the wrapped handler is called,
+		then it's executed wrapped by all middlewares in the right order`, func(t *testing.T) {
+		var (
+			calls []string
+			mux   sync.Mutex
+		)
+		mw := bus.MultiMiddleware(testMw("mw1", &calls, &mux), testMw("mw2", &calls, &mux), testMw("mw3", &calls, &mux))
+
+		rs, err := mw(handlerFixture("a response", nil))(context.Background(), &DispatchableMock{})
+		require.NoError(t, err)
+		require.Equal(t, "a response", rs)
+		require.Equal(t, []string{"mw3", "mw2", "mw1"}, calls)
+	})
+}
+
+func TestRecover(t *testing.T) {
+	t.Run(`Given a handler that panics wrapped by the Recover middleware,
+		when it's called,
+		then an error is returned`, func(t *testing.T) {
+		h := bus.Recover()(func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			panic("boom")
+		})
+
+		_, err := h(context.Background(), &DispatchableMock{})
+		require.ErrorIs(t, err, bus.ErrHandlerPanic)
+		require.Contains(t, err.Error(), "boom")
+	})
+}
+
+func TestBusesUse(t *testing.T) {
+	d := &DispatchableMock{
+		NameFunc: func() string {
+			return "h"
+		},
+	}
+
+	type useRegisterer interface {
+		Register(n string, h bus.Handler, mws ...bus.Middleware)
+		Use(mws ...bus.Middleware)
+	}
+
+	tests := []struct {
+		name   string
+		newBus func(t *testing.T) (useRegisterer, func() error)
+	}{
+		{
+			name: "bus",
+			newBus: func(_ *testing.T) (useRegisterer, func() error) {
+				b := bus.New()
+				return b, func() error {
+					_, err := b.Dispatch(context.Background(), d)
+					return err
+				}
+			},
+		},
+		{
+			name: "concurrent bus",
+			newBus: func(t *testing.T) (useRegisterer, func() error) {
+				b := bus.NewConcurrentBus(time.Hour, 1)
+				ctx, cancel := context.WithCancel(context.Background())
+				t.Cleanup(cancel)
+				go b.Run(ctx)
+				return b, func() error {
+					return (<-b.Dispatch(ctx, d)).Err
+				}
+			},
+		},
+		{
+			name: "fan-out bus",
+			newBus: func(_ *testing.T) (useRegisterer, func() error) {
+				b := bus.NewFanOutBus(bus.Sequential)
+				return b, func() error {
+					_, err := b.Dispatch(context.Background(), d)
+					return err
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(`Given a `+tt.name+` with a global and a per-name middleware,
+			when a dispatchable is dispatched,
+			then the global one wraps the per-name one`, func(t *testing.T) {
+			var (
+				calls []string
+				mux   sync.Mutex
+			)
+			b, dispatch := tt.newBus(t)
+			b.Register("h", handlerFixture(nil, nil), testMw("name", &calls, &mux))
+			b.Use(testMw("global", &calls, &mux))
+
+			require.NoError(t, dispatch())
+			require.Equal(t, []string{"global", "name"}, calls)
+		})
+	}
+}
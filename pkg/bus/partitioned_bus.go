@@ -0,0 +1,253 @@
+package bus
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Partitionable is a dispatchable that belongs to a partition.
+// The dispatchables of the same partition are handled one at a time, in dispatching order.
+type Partitionable interface {
+	PartitionKey() string
+}
+
+// aggregateDispatchable is a dispatchable that belongs to an aggregate, like events.Event
+type aggregateDispatchable interface {
+	AggregateID() uuid.UUID
+}
+
+// PartitionKeyFunc returns the partition key of a dispatchable, or false if it doesn't belong to any partition
+type PartitionKeyFunc func(Dispatchable) (string, bool)
+
+// DefaultPartitionKey returns the PartitionKey of a Partitionable dispatchable,
+// or the aggregate ID of a dispatchable with an AggregateID method, like events.Event
+func DefaultPartitionKey(d Dispatchable) (string, bool) {
+	switch pd := d.(type) {
+	case Partitionable:
+		return pd.PartitionKey(), true
+	case aggregateDispatchable:
+		return pd.AggregateID().String(), true
+	default:
+		return "", false
+	}
+}
+
+// partition identifies a serialization unit. Dispatchables without partition key get a partition of their own.
+type partition struct {
+	key string
+	seq uint64
+}
+
+type partitionedDispatchable struct {
+	dispatchableWithContext
+	p partition
+}
+
+// PartitionedBusOpt is an option for the partitioned bus constructor
+type PartitionedBusOpt func(*PartitionedBus)
+
+// WithPartitionKey sets the function used to get the partition key of the dispatchables.
+// By default, it's DefaultPartitionKey.
+func WithPartitionKey(f PartitionKeyFunc) PartitionedBusOpt {
+	return func(b *PartitionedBus) {
+		b.key = f
+	}
+}
+
+// WithMaxPending sets the maximum number of dispatchables waiting for their partition to be free.
+// Once it's reached, Dispatch blocks. By default, it's 1024.
+func WithMaxPending(n int) PartitionedBusOpt {
+	return func(b *PartitionedBus) {
+		b.maxPending = n
+	}
+}
+
+// PartitionedBus dispatches concurrently across a fixed pool of workers,
+// but the dispatchables of the same partition are handled sequentially, in dispatching order.
+// It gives ordering guarantees per aggregate when the dispatchables are events.
+type PartitionedBus struct {
+	h          registry[Handler]
+	mws        *chain
+	timeout    time.Duration
+	workers    int
+	maxPending int
+	key        PartitionKeyFunc
+	in         chan dispatchableWithContext
+	lc         *lifecycle
+}
+
+// NewPartitionedBus is a constructor
+func NewPartitionedBus(timeout time.Duration, workers int, opts ...PartitionedBusOpt) PartitionedBus {
+	b := PartitionedBus{
+		h:          newRegistry[Handler](),
+		mws:        newChain(),
+		timeout:    timeout,
+		workers:    workers,
+		maxPending: 1024,
+		key:        DefaultPartitionKey,
+		in:         make(chan dispatchableWithContext),
+		lc:         newLifecycle(),
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
+}
+
+// Register adds a new handler to the bus, wrapped by the given middlewares.
+// It's safe to call it while the bus is running.
+func (b PartitionedBus) Register(n string, h Handler, mws ...Middleware) {
+	b.h.set(n, MultiMiddleware(mws...)(h))
+}
+
+// Use adds middlewares that wrap all the handlers of the bus, including the ones already registered.
+// They are applied around the per-name middlewares given at registration.
+func (b PartitionedBus) Use(mws ...Middleware) {
+	b.mws.use(mws...)
+}
+
+// Unregister removes the handler for the given name from the bus
+func (b PartitionedBus) Unregister(n string) {
+	b.h.remove(n)
+}
+
+// Handlers returns the names with a registered handler, sorted
+func (b PartitionedBus) Handlers() []string {
+	return b.h.names()
+}
+
+// Dispatch dispatches a new dispatchable item.
+// Once the bus has been shut down, the response is an ErrBusClosed error.
+func (b PartitionedBus) Dispatch(ctx context.Context, d Dispatchable) <-chan Response {
+	itemCtx, cancel := context.WithCancel(ctx)
+	item, ok := b.lc.track(d, cancel)
+	if !ok {
+		cancel()
+		return errResponse(ErrBusClosed)
+	}
+	select {
+	case b.in <- dispatchableWithContext{ctx: itemCtx, d: d, item: item}:
+	case <-itemCtx.Done():
+		b.lc.finish(item, Response{Err: itemCtx.Err()})
+	}
+	return item.rsChan
+}
+
+// Run start running the bus. When ctx is cancelled, the bus is closed, the pending dispatchables
+// are responded with an ErrBusClosed error, and the in-flight handlers are abandoned.
+// Use Shutdown to stop it gracefully.
+func (b PartitionedBus) Run(ctx context.Context) {
+	var (
+		work = make(chan partitionedDispatchable)
+		done = make(chan partition)
+		quit = make(chan struct{})
+	)
+	defer close(work)
+	defer close(quit)
+	for i := 0; i < b.workers; i++ {
+		go b.work(work, done, quit)
+	}
+
+	var (
+		seq     uint64
+		pending = make(map[partition][]partitionedDispatchable)
+		active  = make(map[partition]bool)
+		ready   []partition
+		count   int
+	)
+	for {
+		var (
+			in   = b.in
+			out  chan partitionedDispatchable
+			next partitionedDispatchable
+		)
+		if count >= b.maxPending {
+			in = nil
+		}
+		if len(ready) > 0 {
+			out, next = work, pending[ready[0]][0]
+		}
+
+		select {
+		case <-ctx.Done():
+			b.lc.close()
+			b.lc.dropPending(ErrBusClosed)
+			return
+		case <-b.lc.drained:
+			return
+		case dwc := <-in:
+			p := partition{}
+			if key, ok := b.key(dwc.d); ok {
+				p.key = key
+			} else {
+				seq++
+				p.seq = seq
+			}
+			if len(pending[p]) == 0 && !active[p] {
+				ready = append(ready, p)
+			}
+			pending[p] = append(pending[p], partitionedDispatchable{dispatchableWithContext: dwc, p: p})
+			count++
+		case out <- next:
+			ready = ready[1:]
+			active[next.p] = true
+			pending[next.p] = pending[next.p][1:]
+			if len(pending[next.p]) == 0 {
+				delete(pending, next.p)
+			}
+			count--
+		case p := <-done:
+			delete(active, p)
+			if len(pending[p]) > 0 {
+				ready = append(ready, p)
+			}
+		}
+	}
+}
+
+func (b PartitionedBus) work(work <-chan partitionedDispatchable, done chan<- partition, quit <-chan struct{}) {
+	for pd := range work {
+		b.handle(pd.dispatchableWithContext)
+		select {
+		case done <- pd.p:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (b PartitionedBus) handle(dwc dispatchableWithContext) {
+	h, ok := b.h.get(dwc.d.Name())
+	if !ok {
+		b.lc.finish(dwc.item, Response{
+			Err: ErrNotDispatchable,
+		})
+		return
+	}
+	if !b.lc.start(dwc.item) {
+		return
+	}
+	if err := dwc.ctx.Err(); err != nil {
+		b.lc.finish(dwc.item, Response{
+			Err: err,
+		})
+		return
+	}
+
+	withTimeoutCtx, cancel := context.WithTimeout(dwc.ctx, b.timeout)
+	defer cancel()
+	rs, err := b.mws.wrap(h)(withTimeoutCtx, dwc.d)
+	b.lc.finish(dwc.item, Response{
+		Response: rs,
+		Err:      err,
+	})
+}
+
+// Shutdown stops the bus gracefully. It stops accepting new dispatchables, which get an ErrBusClosed error,
+// and waits for the pending and in-flight ones to finish. If ctx is done before that, they are cancelled
+// and returned as dropped together with the ctx error.
+func (b PartitionedBus) Shutdown(ctx context.Context) ([]Dispatchable, error) {
+	return b.lc.shutdown(ctx)
+}
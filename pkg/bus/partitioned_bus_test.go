@@ -0,0 +1,173 @@
+package bus_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type keyedDispatchable struct {
+	key string
+	seq int
+}
+
+func (keyedDispatchable) Name() string { return "h" }
+
+func (d keyedDispatchable) PartitionKey() string { return d.key }
+
+type aggregateDispatchable struct {
+	id  uuid.UUID
+	seq int
+}
+
+func (aggregateDispatchable) Name() string { return "h" }
+
+func (d aggregateDispatchable) AggregateID() uuid.UUID { return d.id }
+
+func TestDefaultPartitionKey(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name         string
+		dispatchable bus.Dispatchable
+		expectedKey  string
+		expectedOk   bool
+	}{
+		{
+			name:         `Given a partitionable dispatchable, then its partition key is returned`,
+			dispatchable: keyedDispatchable{key: "k"},
+			expectedKey:  "k",
+			expectedOk:   true,
+		},
+		{
+			name:         `Given a dispatchable with an aggregate ID, then the aggregate ID is returned`,
+			dispatchable: aggregateDispatchable{id: id},
+			expectedKey:  id.String(),
+			expectedOk:   true,
+		},
+		{
+			name:         `Given any other dispatchable, then no key is returned`,
+			dispatchable: &DispatchableMock{},
+		},
+	}
+
+	for _, tt := range tests {
+		key, ok := bus.DefaultPartitionKey(tt.dispatchable)
+		require.Equal(t, tt.expectedOk, ok, tt.name)
+		require.Equal(t, tt.expectedKey, key, tt.name)
+	}
+}
+
+func TestPartitionedBus(t *testing.T) {
+	runBus := func(t *testing.T, workers int, h bus.Handler) bus.PartitionedBus {
+		pbus := bus.NewPartitionedBus(time.Hour, workers)
+		pbus.Register("h", h)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go pbus.Run(ctx)
+		return pbus
+	}
+
+	t.Run(`Given a partitioned bus with several workers,
+		when many dispatchables of the same aggregate are dispatched,
+		then they are handled one at a time in dispatching order`, func(t *testing.T) {
+		var (
+			mux     sync.Mutex
+			running int
+			handled []int
+			id      = uuid.New()
+		)
+		pbus := runBus(t, 4, func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			mux.Lock()
+			running++
+			require.Equal(t, 1, running)
+			mux.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mux.Lock()
+			running--
+			handled = append(handled, d.(aggregateDispatchable).seq)
+			mux.Unlock()
+			return nil, nil
+		})
+
+		var rsChans []<-chan bus.Response
+		for i := 0; i < 20; i++ {
+			rsChans = append(rsChans, pbus.Dispatch(context.Background(), aggregateDispatchable{id: id, seq: i}))
+		}
+		for _, rsChan := range rsChans {
+			require.NoError(t, (<-rsChan).Err)
+		}
+
+		var expected []int
+		for i := 0; i < 20; i++ {
+			expected = append(expected, i)
+		}
+		require.Equal(t, expected, handled)
+	})
+
+	t.Run(`Given a partitioned bus with two workers,
+		when dispatchables of two different partitions are dispatched,
+		then they are handled in parallel`, func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		pbus := runBus(t, 2, func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			// Each handler only finishes once both have started
+			wg.Done()
+			wg.Wait()
+			return nil, nil
+		})
+
+		rs1 := pbus.Dispatch(context.Background(), keyedDispatchable{key: "a"})
+		rs2 := pbus.Dispatch(context.Background(), keyedDispatchable{key: "b"})
+		require.NoError(t, (<-rs1).Err)
+		require.NoError(t, (<-rs2).Err)
+	})
+
+	t.Run(`Given a partitioned bus,
+		when a dispatchable without handler is dispatched,
+		then an error is returned`, func(t *testing.T) {
+		pbus := runBus(t, 1, handlerFixture(nil, nil))
+
+		rs := <-pbus.Dispatch(context.Background(), &DispatchableMock{})
+		require.ErrorIs(t, rs.Err, bus.ErrNotDispatchable)
+	})
+
+	t.Run(`Given a partitioned bus with pending dispatchables,
+		when it's shut down,
+		then they are handled before it finishes and new ones are rejected`, func(t *testing.T) {
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+			once    sync.Once
+		)
+		pbus := runBus(t, 1, func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			once.Do(func() { close(started) })
+			<-release
+			return d, nil
+		})
+
+		rs1 := pbus.Dispatch(context.Background(), keyedDispatchable{key: "a", seq: 1})
+		<-started
+		rs2 := pbus.Dispatch(context.Background(), keyedDispatchable{key: "a", seq: 2})
+
+		shutdownDone := make(chan struct{})
+		go func() {
+			defer close(shutdownDone)
+			dropped, err := pbus.Shutdown(context.Background())
+			require.NoError(t, err)
+			require.Empty(t, dropped)
+		}()
+		close(release)
+		<-shutdownDone
+		require.Equal(t, keyedDispatchable{key: "a", seq: 1}, (<-rs1).Response)
+		require.Equal(t, keyedDispatchable{key: "a", seq: 2}, (<-rs2).Response)
+		require.ErrorIs(t, (<-pbus.Dispatch(context.Background(), keyedDispatchable{key: "b"})).Err, bus.ErrBusClosed)
+	})
+}
@@ -0,0 +1,57 @@
+package bus
+
+import (
+	"sort"
+	"sync"
+)
+
+// registry is a thread-safe set of handlers indexed by dispatchable name
+type registry[T any] struct {
+	mux *sync.RWMutex
+	h   map[string]T
+}
+
+func newRegistry[T any]() registry[T] {
+	return registry[T]{
+		mux: &sync.RWMutex{},
+		h:   make(map[string]T),
+	}
+}
+
+func (r registry[T]) set(n string, h T) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.h[n] = h
+}
+
+// update replaces the handler for n with the one returned by f, which receives the current one
+func (r registry[T]) update(n string, f func(T) T) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.h[n] = f(r.h[n])
+}
+
+func (r registry[T]) get(n string) (T, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	h, ok := r.h[n]
+	return h, ok
+}
+
+func (r registry[T]) remove(n string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.h, n)
+}
+
+// names returns the registered names sorted
+func (r registry[T]) names() []string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	names := make([]string, 0, len(r.h))
+	for n := range r.h {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
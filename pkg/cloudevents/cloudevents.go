@@ -0,0 +1,179 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents spec version supported
+const SpecVersion = "1.0"
+
+// ErrInvalid is returned when a CloudEvent doesn't comply with the spec
+var ErrInvalid = errors.New("invalid cloud event")
+
+var extensionName = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+
+// CloudEvent is a CloudEvents 1.0 event. The extension attributes are kept as strings.
+type CloudEvent struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	Extensions      map[string]string
+	Data            []byte
+}
+
+// Validate checks the required attributes and the extension names
+func (ce CloudEvent) Validate() error {
+	switch {
+	case ce.ID == "":
+		return fmt.Errorf("%w: missing id", ErrInvalid)
+	case ce.Source == "":
+		return fmt.Errorf("%w: missing source", ErrInvalid)
+	case ce.SpecVersion != SpecVersion:
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalid, ce.SpecVersion)
+	case ce.Type == "":
+		return fmt.Errorf("%w: missing type", ErrInvalid)
+	}
+	for name := range ce.Extensions {
+		if !extensionName.MatchString(name) || isContextAttribute(name) {
+			return fmt.Errorf("%w: extension name %q", ErrInvalid, name)
+		}
+	}
+	return nil
+}
+
+func isContextAttribute(name string) bool {
+	switch name {
+	case "id", "source", "specversion", "type", "subject", "time", "datacontenttype", "dataschema", "data", "data_base64":
+		return true
+	}
+	return false
+}
+
+// isJSON returns whether the content type is JSON, so the data is a JSON value in the structured mode
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")
+}
+
+// MarshalJSON encodes the event in the JSON format, used by the structured content mode
+func (ce CloudEvent) MarshalJSON() ([]byte, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{
+		"id":          ce.ID,
+		"source":      ce.Source,
+		"specversion": ce.SpecVersion,
+		"type":        ce.Type,
+	}
+	if ce.Subject != "" {
+		m["subject"] = ce.Subject
+	}
+	if !ce.Time.IsZero() {
+		m["time"] = ce.Time.Format(time.RFC3339Nano)
+	}
+	if ce.DataContentType != "" {
+		m["datacontenttype"] = ce.DataContentType
+	}
+	if ce.DataSchema != "" {
+		m["dataschema"] = ce.DataSchema
+	}
+	for k, v := range ce.Extensions {
+		m[k] = v
+	}
+	if ce.Data != nil {
+		if isJSON(ce.DataContentType) && json.Valid(ce.Data) {
+			m["data"] = json.RawMessage(ce.Data)
+		} else {
+			m["data_base64"] = base64.StdEncoding.EncodeToString(ce.Data)
+		}
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes an event in the JSON format, used by the structured content mode
+func (ce *CloudEvent) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	*ce = CloudEvent{}
+	for k, v := range m {
+		var err error
+		switch k {
+		case "id":
+			err = json.Unmarshal(v, &ce.ID)
+		case "source":
+			err = json.Unmarshal(v, &ce.Source)
+		case "specversion":
+			err = json.Unmarshal(v, &ce.SpecVersion)
+		case "type":
+			err = json.Unmarshal(v, &ce.Type)
+		case "subject":
+			err = json.Unmarshal(v, &ce.Subject)
+		case "time":
+			var t string
+			if err = json.Unmarshal(v, &t); err == nil {
+				ce.Time, err = time.Parse(time.RFC3339Nano, t)
+			}
+		case "datacontenttype":
+			err = json.Unmarshal(v, &ce.DataContentType)
+		case "dataschema":
+			err = json.Unmarshal(v, &ce.DataSchema)
+		case "data":
+			ce.Data = v
+			if !isJSON(contentTypeOf(m)) {
+				// Not JSON data is encoded as a JSON string
+				var s string
+				if err = json.Unmarshal(v, &s); err == nil {
+					ce.Data = []byte(s)
+				}
+			}
+		case "data_base64":
+			var s string
+			if err = json.Unmarshal(v, &s); err == nil {
+				ce.Data, err = base64.StdEncoding.DecodeString(s)
+			}
+		default:
+			if ce.Extensions == nil {
+				ce.Extensions = make(map[string]string)
+			}
+			var s string
+			if json.Unmarshal(v, &s) == nil {
+				ce.Extensions[k] = s
+			} else {
+				ce.Extensions[k] = string(bytes.TrimSpace(v))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrInvalid, k, err)
+		}
+	}
+	return ce.Validate()
+}
+
+func contentTypeOf(m map[string]json.RawMessage) string {
+	var ct string
+	_ = json.Unmarshal(m["datacontenttype"], &ct)
+	return ct
+}
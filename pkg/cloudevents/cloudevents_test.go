@@ -0,0 +1,78 @@
+package cloudevents_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/cloudevents"
+
+	"github.com/stretchr/testify/require"
+)
+
+func cloudEvent() cloudevents.CloudEvent {
+	return cloudevents.CloudEvent{
+		ID:              "A234-1234-1234",
+		Source:          "/mycontext",
+		SpecVersion:     cloudevents.SpecVersion,
+		Type:            "com.example.someevent",
+		Subject:         "larger-context",
+		Time:            time.Date(2018, 4, 5, 17, 31, 0, 0, time.UTC),
+		DataContentType: "application/json",
+		Extensions:      map[string]string{"comexampleextension1": "value"},
+		Data:            []byte(`{"key":"value"}`),
+	}
+}
+
+func TestCloudEventJSON(t *testing.T) {
+	t.Run(`Given a cloud event with JSON data, when it's marshalled, then the data is embedded as JSON`, func(t *testing.T) {
+		ce := cloudEvent()
+		b, err := json.Marshal(ce)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"specversion": "1.0",
+			"type": "com.example.someevent",
+			"source": "/mycontext",
+			"subject": "larger-context",
+			"id": "A234-1234-1234",
+			"time": "2018-04-05T17:31:00Z",
+			"comexampleextension1": "value",
+			"datacontenttype": "application/json",
+			"data": {"key": "value"}
+		}`, string(b))
+
+		var got cloudevents.CloudEvent
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.JSONEq(t, string(ce.Data), string(got.Data))
+		got.Data = ce.Data
+		require.Equal(t, ce, got)
+	})
+
+	t.Run(`Given a cloud event with binary data, when it's round-tripped, then the data is encoded in base64`, func(t *testing.T) {
+		ce := cloudEvent()
+		ce.DataContentType = "application/octet-stream"
+		ce.Data = []byte{0, 1, 2, 255}
+		b, err := json.Marshal(ce)
+		require.NoError(t, err)
+		require.Contains(t, string(b), `"data_base64":"AAEC/w=="`)
+
+		var got cloudevents.CloudEvent
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.Equal(t, ce, got)
+	})
+
+	t.Run(`Given a cloud event without the required attributes, when it's unmarshalled, then an error is returned`, func(t *testing.T) {
+		var got cloudevents.CloudEvent
+		err := json.Unmarshal([]byte(`{"specversion":"1.0","type":"t","source":"s"}`), &got)
+		require.ErrorIs(t, err, cloudevents.ErrInvalid)
+
+		err = json.Unmarshal([]byte(`{"specversion":"0.3","type":"t","source":"s","id":"1"}`), &got)
+		require.ErrorIs(t, err, cloudevents.ErrInvalid)
+	})
+
+	t.Run(`Given a cloud event with an invalid extension name, when it's validated, then an error is returned`, func(t *testing.T) {
+		ce := cloudEvent()
+		ce.Extensions = map[string]string{"Not-Valid": "value"}
+		require.ErrorIs(t, ce.Validate(), cloudevents.ErrInvalid)
+	})
+}
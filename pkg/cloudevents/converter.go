@@ -0,0 +1,143 @@
+package cloudevents
+
+import (
+	"strconv"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// Extension attributes used for the events metadata
+const (
+	ExtCorrelationID    = "correlationid"
+	ExtCausationID      = "causationid"
+	ExtAggregateVersion = "aggregateversion"
+	ExtSchemaVersion    = "schemaversion"
+)
+
+// ConverterOpt is an option for the converter constructor
+type ConverterOpt func(*Converter)
+
+// WithContentType sets the content type of the events data. It must match the registry encoder.
+// By default, it's application/json.
+func WithContentType(contentType string) ConverterOpt {
+	return func(c *Converter) {
+		c.contentType = contentType
+	}
+}
+
+// Converter converts events to CloudEvents and back
+type Converter struct {
+	registry    events.Registry
+	source      string
+	contentType string
+}
+
+// NewConverter is a constructor. The registry encodes the events data, and decodes it back to the registered types.
+// The source identifies the context where the events happen, like the service that raises them.
+func NewConverter(registry events.Registry, source string, opts ...ConverterOpt) Converter {
+	c := Converter{
+		registry:    registry,
+		source:      source,
+		contentType: "application/json",
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// ToCloudEvent converts an event. The event name is the type, the aggregate ID the subject,
+// the body the data, and the metadata is kept in the time and in extension attributes.
+// The metadata headers become extensions too, so their keys must be valid extension names.
+func (c Converter) ToCloudEvent(e events.Event) (CloudEvent, error) {
+	raw, err := c.registry.EncodeRaw(e)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	if raw.ID == uuid.Nil {
+		raw.ID = uuid.New()
+	}
+	ce := CloudEvent{
+		ID:              raw.ID.String(),
+		Source:          c.source,
+		SpecVersion:     SpecVersion,
+		Type:            raw.Name,
+		Time:            raw.Metadata.OccurredAt,
+		DataContentType: c.contentType,
+		Extensions:      make(map[string]string),
+	}
+	if len(raw.Body) > 0 {
+		ce.Data = raw.Body
+	}
+	if raw.AggregateID != uuid.Nil {
+		ce.Subject = raw.AggregateID.String()
+	}
+	for k, v := range raw.Metadata.Headers {
+		ce.Extensions[k] = v
+	}
+	md := raw.Metadata
+	if md.CorrelationID != uuid.Nil {
+		ce.Extensions[ExtCorrelationID] = md.CorrelationID.String()
+	}
+	if md.CausationID != uuid.Nil {
+		ce.Extensions[ExtCausationID] = md.CausationID.String()
+	}
+	if md.AggregateVersion != 0 {
+		ce.Extensions[ExtAggregateVersion] = strconv.Itoa(md.AggregateVersion)
+	}
+	if md.SchemaVersion != 0 {
+		ce.Extensions[ExtSchemaVersion] = strconv.Itoa(md.SchemaVersion)
+	}
+	return ce, ce.Validate()
+}
+
+// FromCloudEvent converts a CloudEvent back to events. It can return several ones when the registry upcasts it.
+// CloudEvents IDs that are not UUIDs are mapped to name-based UUIDs, so the same CloudEvent gets always the same ID.
+// The extensions other than the metadata ones become metadata headers, and so does the subject if it's not an UUID.
+func (c Converter) FromCloudEvent(ce CloudEvent) ([]events.Event, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(ce.ID)
+	if err != nil {
+		id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(ce.Source+"#"+ce.ID))
+	}
+	raw := events.RawEvent{
+		ID:       id,
+		Name:     ce.Type,
+		Body:     ce.Data,
+		Metadata: events.Metadata{OccurredAt: ce.Time},
+	}
+	headers := func() map[string]string {
+		if raw.Metadata.Headers == nil {
+			raw.Metadata.Headers = make(map[string]string)
+		}
+		return raw.Metadata.Headers
+	}
+	if ce.Subject != "" {
+		if raw.AggregateID, err = uuid.Parse(ce.Subject); err != nil {
+			headers()["subject"] = ce.Subject
+		}
+	}
+	for k, v := range ce.Extensions {
+		err = nil
+		switch k {
+		case ExtCorrelationID:
+			raw.Metadata.CorrelationID, err = uuid.Parse(v)
+		case ExtCausationID:
+			raw.Metadata.CausationID, err = uuid.Parse(v)
+		case ExtAggregateVersion:
+			raw.Metadata.AggregateVersion, err = strconv.Atoi(v)
+		case ExtSchemaVersion:
+			raw.Metadata.SchemaVersion, err = strconv.Atoi(v)
+		default:
+			headers()[k] = v
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.registry.DecodeRaw(raw)
+}
@@ -0,0 +1,70 @@
+package cloudevents_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/cloudevents"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type userAdded struct {
+	UserName string `json:"user_name"`
+}
+
+func TestConverter(t *testing.T) {
+	registry := events.NewRegistry(events.JSONEncoder{})
+	registry.Register(events.NewEventBasic(uuid.Nil, "user_added", userAdded{}))
+	c := cloudevents.NewConverter(registry, "/users")
+
+	e := events.NewEventBasic(uuid.New(), "user_added", userAdded{UserName: "Bond"},
+		events.WithOccurredAt(time.Now().UTC().Truncate(time.Millisecond)),
+		events.WithAggregateVersion(2),
+		events.WithCorrelation(uuid.New(), uuid.New()),
+		events.WithHeader("tenant", "acme"),
+	)
+
+	for name, mode := range map[string]cloudevents.Mode{"binary": cloudevents.Binary, "structured": cloudevents.Structured} {
+		t.Run(`Given an event, when it's sent as a cloud event in `+name+` mode, then it's received as is`, func(t *testing.T) {
+			ce, err := c.ToCloudEvent(e)
+			require.NoError(t, err)
+			require.Equal(t, e.ID.String(), ce.ID)
+			require.Equal(t, "user_added", ce.Type)
+			require.Equal(t, e.AggregateID().String(), ce.Subject)
+			require.Equal(t, `{"user_name":"Bond"}`, string(ce.Data))
+			require.Equal(t, "acme", ce.Extensions["tenant"])
+
+			h, body, err := cloudevents.EncodeHTTP(ce, mode)
+			require.NoError(t, err)
+			got, err := cloudevents.DecodeHTTP(h, body)
+			require.NoError(t, err)
+
+			evs, err := c.FromCloudEvent(got)
+			require.NoError(t, err)
+			require.Equal(t, []events.Event{e}, evs)
+		})
+	}
+
+	t.Run(`Given a cloud event from another system, when it's converted, then its ID is mapped to an UUID`, func(t *testing.T) {
+		ce := cloudevents.CloudEvent{
+			ID:          "A234-1234-1234",
+			Source:      "/other",
+			SpecVersion: cloudevents.SpecVersion,
+			Type:        "user_added",
+			Subject:     "user-7",
+			Data:        []byte(`{"user_name":"Q"}`),
+		}
+		evs, err := c.FromCloudEvent(ce)
+		require.NoError(t, err)
+		again, err := c.FromCloudEvent(ce)
+		require.NoError(t, err)
+
+		eb := evs[0].(events.EventBasic)
+		require.Equal(t, again[0].(events.EventBasic).EventID(), eb.EventID())
+		require.Equal(t, userAdded{UserName: "Q"}, eb.Body())
+		require.Equal(t, "user-7", eb.Metadata().Headers["subject"])
+	})
+}
@@ -0,0 +1,177 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is a CloudEvents HTTP content mode
+type Mode int
+
+const (
+	// Binary mode puts the event attributes in ce- headers, and the data in the HTTP body
+	Binary Mode = iota
+	// Structured mode puts the whole event in the HTTP body, in the JSON format
+	Structured
+)
+
+// ContentTypeStructured is the content type of the structured content mode
+const ContentTypeStructured = "application/cloudevents+json"
+
+const headerPrefix = "Ce-"
+
+// EncodeHTTP returns the HTTP headers and body of the event in the given content mode
+func EncodeHTTP(ce CloudEvent, mode Mode) (http.Header, []byte, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, nil, err
+	}
+	h := make(http.Header)
+	if mode == Structured {
+		b, err := json.Marshal(ce)
+		if err != nil {
+			return nil, nil, err
+		}
+		h.Set("Content-Type", ContentTypeStructured+"; charset=utf-8")
+		return h, b, nil
+	}
+
+	set := func(name, value string) {
+		if value != "" {
+			h.Set(headerPrefix+name, encodeHeaderValue(value))
+		}
+	}
+	set("id", ce.ID)
+	set("source", ce.Source)
+	set("specversion", ce.SpecVersion)
+	set("type", ce.Type)
+	set("subject", ce.Subject)
+	set("dataschema", ce.DataSchema)
+	if !ce.Time.IsZero() {
+		set("time", ce.Time.Format(time.RFC3339Nano))
+	}
+	for k, v := range ce.Extensions {
+		set(k, v)
+	}
+	if ce.DataContentType != "" {
+		h.Set("Content-Type", ce.DataContentType)
+	}
+	return h, ce.Data, nil
+}
+
+// DecodeHTTP returns the event of an HTTP message, detecting its content mode from its content type
+func DecodeHTTP(h http.Header, body []byte) (CloudEvent, error) {
+	if mt, _, err := mime.ParseMediaType(h.Get("Content-Type")); err == nil && mt == ContentTypeStructured {
+		var ce CloudEvent
+		err := json.Unmarshal(body, &ce)
+		return ce, err
+	}
+
+	ce := CloudEvent{DataContentType: h.Get("Content-Type")}
+	if len(body) > 0 {
+		ce.Data = body
+	}
+	for k, vs := range h {
+		if !strings.HasPrefix(http.CanonicalHeaderKey(k), headerPrefix) || len(vs) == 0 {
+			continue
+		}
+		name := strings.ToLower(k[len(headerPrefix):])
+		v, err := decodeHeaderValue(vs[0])
+		if err != nil {
+			return CloudEvent{}, fmt.Errorf("%w: %s: %s", ErrInvalid, name, err)
+		}
+		switch name {
+		case "id":
+			ce.ID = v
+		case "source":
+			ce.Source = v
+		case "specversion":
+			ce.SpecVersion = v
+		case "type":
+			ce.Type = v
+		case "subject":
+			ce.Subject = v
+		case "dataschema":
+			ce.DataSchema = v
+		case "time":
+			if ce.Time, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return CloudEvent{}, fmt.Errorf("%w: time: %s", ErrInvalid, err)
+			}
+		default:
+			if ce.Extensions == nil {
+				ce.Extensions = make(map[string]string)
+			}
+			ce.Extensions[name] = v
+		}
+	}
+	return ce, ce.Validate()
+}
+
+// WriteRequest sets the event as the headers and body of the request, in the given content mode
+func WriteRequest(req *http.Request, ce CloudEvent, mode Mode) error {
+	h, body, err := EncodeHTTP(ce, mode)
+	if err != nil {
+		return err
+	}
+	for k, vs := range h {
+		req.Header[k] = vs
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
+
+// ReadRequest returns the event of the request, in any content mode
+func ReadRequest(req *http.Request) (CloudEvent, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return CloudEvent{}, err
+		}
+	}
+	return DecodeHTTP(req.Header, body)
+}
+
+// encodeHeaderValue percent-encodes the characters that are not printable ASCII, spaces, double quotes and percents,
+// as the HTTP binding spec requires
+func encodeHeaderValue(v string) string {
+	var b strings.Builder
+	for _, c := range []byte(v) {
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func decodeHeaderValue(v string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] != '%' {
+			b.WriteByte(v[i])
+			continue
+		}
+		if i+2 >= len(v) {
+			return "", fmt.Errorf("malformed percent-encoding %q", v)
+		}
+		c, err := strconv.ParseUint(v[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("malformed percent-encoding %q", v)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
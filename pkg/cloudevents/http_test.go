@@ -0,0 +1,51 @@
+package cloudevents_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/cloudevents"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP(t *testing.T) {
+	t.Run(`Given a cloud event, when it's written in binary mode, then its attributes are set as ce- headers`, func(t *testing.T) {
+		ce := cloudEvent()
+		ce.Extensions["note"] = `100% "quoted" ñ`
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		require.NoError(t, cloudevents.WriteRequest(req, ce, cloudevents.Binary))
+
+		require.Equal(t, "A234-1234-1234", req.Header.Get("ce-id"))
+		require.Equal(t, "1.0", req.Header.Get("ce-specversion"))
+		require.Equal(t, "2018-04-05T17:31:00Z", req.Header.Get("ce-time"))
+		require.Equal(t, "100%25%20%22quoted%22%20%C3%B1", req.Header.Get("ce-note"))
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		got, err := cloudevents.ReadRequest(req)
+		require.NoError(t, err)
+		require.Equal(t, ce, got)
+	})
+
+	t.Run(`Given a cloud event, when it's written in structured mode, then it's the JSON body`, func(t *testing.T) {
+		ce := cloudEvent()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		require.NoError(t, cloudevents.WriteRequest(req, ce, cloudevents.Structured))
+		require.True(t, strings.HasPrefix(req.Header.Get("Content-Type"), cloudevents.ContentTypeStructured))
+		require.Empty(t, req.Header.Get("ce-id"))
+
+		got, err := cloudevents.ReadRequest(req)
+		require.NoError(t, err)
+		require.Equal(t, ce.ID, got.ID)
+		require.JSONEq(t, string(ce.Data), string(got.Data))
+	})
+
+	t.Run(`Given an HTTP request without the required ce- headers, when it's read, then an error is returned`, func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		req.Header.Set("ce-type", "com.example.someevent")
+		_, err := cloudevents.ReadRequest(req)
+		require.ErrorIs(t, err, cloudevents.ErrInvalid)
+	})
+}
@@ -0,0 +1,102 @@
+// Package codec encodes and decodes the dispatchables, commands, queries and events, to be persisted or carried
+// to other processes. Its Registry implements the Codec interfaces of the deadletter, scheduler and transport packages.
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+)
+
+// ErrUnknownDispatchable is returned when decoding a dispatchable whose name has not been registered
+var ErrUnknownDispatchable = errors.New("unknown dispatchable")
+
+// Opt is an option for the registry constructor
+type Opt func(*Registry)
+
+// WithEvents sets the events registry the events are delegated to, like one with upcasters.
+// It must use the same encoder as the registry. By default, a new one is used.
+func WithEvents(r events.Registry) Opt {
+	return func(reg *Registry) {
+		reg.events = r
+	}
+}
+
+// Registry maps the dispatchable names to their Go types, to encode the dispatchables and decode them back.
+// The events are delegated to an events.Registry, so events.EventBasic events are encoded losslessly,
+// with their metadata. The other dispatchables, like commands, are encoded with their exported fields.
+type Registry struct {
+	mux    *sync.RWMutex
+	enc    events.Encoder
+	events events.Registry
+	types  map[string]reflect.Type
+}
+
+// NewRegistry is a constructor
+func NewRegistry(enc events.Encoder, opts ...Opt) Registry {
+	r := Registry{
+		mux:    &sync.RWMutex{},
+		enc:    enc,
+		events: events.NewRegistry(enc),
+		types:  make(map[string]reflect.Type),
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// Register sets the types the dispatchables with the name of each prototype are decoded to.
+// A prototype is a value of the dispatchable type, a pointer or not. The events are registered into
+// the events registry, see events.Registry.Register.
+func (r Registry) Register(prototypes ...bus.Dispatchable) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, p := range prototypes {
+		if e, ok := p.(events.Event); ok {
+			r.events.Register(e)
+			delete(r.types, p.Name())
+			continue
+		}
+		r.types[p.Name()] = reflect.TypeOf(p)
+	}
+}
+
+// Encode encodes the dispatchable
+func (r Registry) Encode(d bus.Dispatchable) ([]byte, error) {
+	if e, ok := d.(events.Event); ok {
+		return r.events.Encode(e)
+	}
+	return r.enc.Marshal(d)
+}
+
+// Decode decodes a dispatchable with the given name to its registered type
+func (r Registry) Decode(name string, data []byte) (bus.Dispatchable, error) {
+	r.mux.RLock()
+	t, ok := r.types[name]
+	r.mux.RUnlock()
+	if !ok {
+		e, err := r.events.Decode(name, data)
+		if errors.Is(err, events.ErrUnknownEvent) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownDispatchable, name)
+		}
+		return e, err
+	}
+
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if err := r.enc.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	if isPtr {
+		return v.Interface().(bus.Dispatchable), nil
+	}
+	return v.Elem().Interface().(bus.Dispatchable), nil
+}
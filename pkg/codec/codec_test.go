@@ -0,0 +1,60 @@
+package codec_test
+
+import (
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/codec"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type addUser struct {
+	UserName string
+}
+
+func (addUser) Name() string { return "add.user" }
+
+type userAdded struct {
+	UserName string
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run(`Given a registry, when commands and events are encoded and decoded, then they're decoded to their types, the events with their metadata`, func(t *testing.T) {
+		r := codec.NewRegistry(events.JSONEncoder{})
+		r.Register(&addUser{}, events.NewEventBasic(uuid.Nil, "user.added", userAdded{}))
+
+		cmd := addUser{UserName: "Bond"}
+		b, err := r.Encode(cmd)
+		require.NoError(t, err)
+		d, err := r.Decode(cmd.Name(), b)
+		require.NoError(t, err)
+		require.Equal(t, &cmd, d)
+
+		ev := events.NewEventBasic(uuid.New(), "user.added", userAdded{UserName: "Bond"}, events.WithCorrelation(uuid.New(), uuid.New()))
+		b, err = r.Encode(ev)
+		require.NoError(t, err)
+		d, err = r.Decode(ev.Name(), b)
+		require.NoError(t, err)
+		require.Equal(t, ev, d)
+	})
+
+	t.Run(`Given a registry with an events registry with upcasters, when an old event is decoded, then it's upcast`, func(t *testing.T) {
+		upcasters := events.NewUpcasters()
+		upcasters.Register("user.created", 1, events.Rename("user.added"))
+		r := codec.NewRegistry(events.JSONEncoder{}, codec.WithEvents(events.NewRegistry(events.JSONEncoder{}, events.WithUpcasters(upcasters))))
+		r.Register(events.NewEventBasic(uuid.Nil, "user.added", nil))
+
+		b, err := r.Encode(events.NewEventBasic(uuid.New(), "user.created", nil))
+		require.NoError(t, err)
+		d, err := r.Decode("user.created", b)
+		require.NoError(t, err)
+		require.Equal(t, "user.added", d.Name())
+	})
+
+	t.Run(`Given a registry, when a not registered dispatchable is decoded, then an error is returned`, func(t *testing.T) {
+		_, err := codec.NewRegistry(events.JSONEncoder{}).Decode("unknown", []byte(`{}`))
+		require.ErrorIs(t, err, codec.ErrUnknownDispatchable)
+	})
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/theskyinflames/cqrs-eda/pkg/bus"
 	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
 )
 
 //go:generate moq -stub -out zmock_cqrs_event_test.go -pkg cqrs_test . Event
@@ -71,21 +73,35 @@ type Bus interface {
 	Dispatch(context.Context, bus.Dispatchable) (interface{}, error)
 }
 
-// ChEventMw is a domain events handler middleware
+// ChEventMw is a domain events handler middleware. The correlation and causation IDs of the context
+// (see events.ContextWithCorrelation) are propagated to the dispatched events. If there are none,
+// a new correlation ID is set for the command.
 func ChEventMw(eventsBus Bus) CommandHandlerMiddleware {
 	return func(ch CommandHandler) CommandHandler {
 		return CommandHandlerFunc(func(ctx context.Context, cmd Command) ([]events.Event, error) {
+			ctx = CorrelatedContext(ctx)
 			evs, err := ch.Handle(ctx, cmd)
-			if err == nil {
-				for _, e := range evs {
-					eventsBus.Dispatch(ctx, e)
-				}
+			if err != nil {
+				return evs, err
 			}
-			return evs, err
+			propagated := make([]events.Event, len(evs))
+			for i, e := range evs {
+				propagated[i] = events.Propagate(ctx, e)
+				eventsBus.Dispatch(ctx, propagated[i])
+			}
+			return propagated, nil
 		})
 	}
 }
 
+// CorrelatedContext returns ctx if it carries a correlation ID, or a copy of it with a new one otherwise
+func CorrelatedContext(ctx context.Context) context.Context {
+	if _, _, ok := events.CorrelationFromContext(ctx); ok {
+		return ctx
+	}
+	return events.ContextWithCorrelation(ctx, uuid.New(), uuid.Nil)
+}
+
 // Query is a CQRS query
 type Query interface {
 	Name() string
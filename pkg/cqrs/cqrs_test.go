@@ -9,6 +9,7 @@ import (
 	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
 	"github.com/theskyinflames/cqrs-eda/pkg/events"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -190,4 +191,81 @@ func TestChEventMw(t *testing.T) {
 		require.Len(t, evs, 1)
 		require.Equal(t, ev, evs[0])
 	})
+
+	t.Run(`Given a events ch middleware with a events bus,
+	when the ch returns events within a context with correlation,
+	then the correlation and causation IDs are propagated to the dispatched events`, func(t *testing.T) {
+		var (
+			correlationID = uuid.New()
+			causationID   = uuid.New()
+			evBus         = &BusMock{
+				DispatchFunc: func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+					return nil, nil
+				},
+			}
+			ch = &CommandHandlerMock{
+				HandleFunc: func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+					return []events.Event{events.NewEventBasic(uuid.New(), "entity.changed", nil)}, nil
+				},
+			}
+		)
+
+		ctx := events.ContextWithCorrelation(context.Background(), correlationID, causationID)
+		evs, err := cqrs.ChEventMw(evBus)(ch).Handle(ctx, &CommandMock{})
+		require.NoError(t, err)
+		require.Len(t, evBus.DispatchCalls(), 1)
+		require.Equal(t, evs[0], evBus.DispatchCalls()[0].Dispatchable)
+		md := evs[0].(events.Enveloped).Metadata()
+		require.Equal(t, correlationID, md.CorrelationID)
+		require.Equal(t, causationID, md.CausationID)
+	})
+
+	t.Run(`Given a events ch middleware with a events bus,
+	when the ch returns events within a context without correlation,
+	then they share a new correlation ID`, func(t *testing.T) {
+		var (
+			evBus = &BusMock{
+				DispatchFunc: func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+					return nil, nil
+				},
+			}
+			ch = &CommandHandlerMock{
+				HandleFunc: func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+					return []events.Event{
+						events.NewEventBasic(uuid.New(), "entity.changed", nil),
+						events.NewEventBasic(uuid.New(), "entity.changed", nil),
+					}, nil
+				},
+			}
+		)
+
+		evs, err := cqrs.ChEventMw(evBus)(ch).Handle(context.Background(), &CommandMock{})
+		require.NoError(t, err)
+		correlationID := evs[0].(events.Enveloped).Metadata().CorrelationID
+		require.NotEqual(t, uuid.Nil, correlationID)
+		require.Equal(t, correlationID, evs[1].(events.Enveloped).Metadata().CorrelationID)
+	})
+
+	t.Run(`Given a events ch middleware with a events bus,
+	when the ch returns events,
+	then the slice returned by the ch is not modified`, func(t *testing.T) {
+		var (
+			evBus = &BusMock{
+				DispatchFunc: func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+					return nil, nil
+				},
+			}
+			handled = []events.Event{events.NewEventBasic(uuid.New(), "entity.changed", nil)}
+			ch      = &CommandHandlerMock{
+				HandleFunc: func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+					return handled, nil
+				},
+			}
+		)
+
+		evs, err := cqrs.ChEventMw(evBus)(ch).Handle(context.Background(), &CommandMock{})
+		require.NoError(t, err)
+		require.NotEqual(t, uuid.Nil, evs[0].(events.Enveloped).Metadata().CorrelationID)
+		require.Equal(t, uuid.Nil, handled[0].(events.Enveloped).Metadata().CorrelationID)
+	})
 }
@@ -0,0 +1,120 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+)
+
+// ErrUnexpectedCommand is returned when a dispatchable is not of the command type expected by its handler
+var ErrUnexpectedCommand = errors.New("unexpected command")
+
+// ErrUnexpectedQuery is returned when a dispatchable is not of the query type expected by its handler
+var ErrUnexpectedQuery = errors.New("unexpected query")
+
+// ErrUnexpectedQueryResult is returned when a query handler result is not of the expected type
+var ErrUnexpectedQueryResult = errors.New("unexpected query result")
+
+// TypedCommandHandler handles a command of a concrete type
+type TypedCommandHandler[C Command] interface {
+	Handle(ctx context.Context, cmd C) ([]events.Event, error)
+}
+
+// TypedCommandHandlerFunc is a function that implements TypedCommandHandler interface
+type TypedCommandHandlerFunc[C Command] func(ctx context.Context, cmd C) ([]events.Event, error)
+
+// Handle implements the TypedCommandHandler interface
+func (chf TypedCommandHandlerFunc[C]) Handle(ctx context.Context, cmd C) ([]events.Event, error) {
+	return chf(ctx, cmd)
+}
+
+// BusTypedChHandler adapts a typed command handler to a bus handler
+func BusTypedChHandler[C Command](ch TypedCommandHandler[C]) bus.Handler {
+	return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+		cmd, ok := d.(C)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s (%T)", ErrUnexpectedCommand, d.Name(), d)
+		}
+		return ch.Handle(ctx, cmd)
+	}
+}
+
+// CommandBus is a type-safe commands bus for the commands of type C. It's backed by a bus.Bus,
+// which can be shared by the command buses of other command types, so one bus hosts the whole command set.
+type CommandBus[C Command] struct {
+	b bus.Bus
+}
+
+// NewCommandBus is a constructor. The commands are dispatched through the given bus.
+func NewCommandBus[C Command](b bus.Bus) CommandBus[C] {
+	return CommandBus[C]{b: b}
+}
+
+// Register adds a new command handler to the bus, under the given command name
+func (cb CommandBus[C]) Register(n string, ch TypedCommandHandler[C], mws ...bus.Middleware) {
+	cb.b.Register(n, BusTypedChHandler(ch), mws...)
+}
+
+// Dispatch dispatches a command to its handler and returns the events it raised
+func (cb CommandBus[C]) Dispatch(ctx context.Context, cmd C) ([]events.Event, error) {
+	rs, err := cb.b.Dispatch(ctx, cmd)
+	evs, _ := rs.([]events.Event)
+	return evs, err
+}
+
+// TypedQueryHandler handles a query of a concrete type and returns a typed result
+type TypedQueryHandler[Q Query, R QueryResult] interface {
+	Handle(ctx context.Context, q Q) (R, error)
+}
+
+// TypedQueryHandlerFunc is a function that implements TypedQueryHandler interface
+type TypedQueryHandlerFunc[Q Query, R QueryResult] func(ctx context.Context, q Q) (R, error)
+
+// Handle implements the TypedQueryHandler interface
+func (qhf TypedQueryHandlerFunc[Q, R]) Handle(ctx context.Context, q Q) (R, error) {
+	return qhf(ctx, q)
+}
+
+// BusTypedQhHandler adapts a typed query handler to a bus handler
+func BusTypedQhHandler[Q Query, R QueryResult](qh TypedQueryHandler[Q, R]) bus.Handler {
+	return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+		q, ok := d.(Q)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s (%T)", ErrUnexpectedQuery, d.Name(), d)
+		}
+		return qh.Handle(ctx, q)
+	}
+}
+
+// QueryBus is a type-safe queries bus for the queries of type Q. It's backed by a bus.Bus,
+// which can be shared by the query buses of other query types, so one bus hosts the whole query set.
+type QueryBus[Q Query, R QueryResult] struct {
+	b bus.Bus
+}
+
+// NewQueryBus is a constructor. The queries are dispatched through the given bus.
+func NewQueryBus[Q Query, R QueryResult](b bus.Bus) QueryBus[Q, R] {
+	return QueryBus[Q, R]{b: b}
+}
+
+// Register adds a new query handler to the bus, under the given query name
+func (qb QueryBus[Q, R]) Register(n string, qh TypedQueryHandler[Q, R], mws ...bus.Middleware) {
+	qb.b.Register(n, BusTypedQhHandler(qh), mws...)
+}
+
+// Dispatch dispatches a query to its handler and returns its typed result
+func (qb QueryBus[Q, R]) Dispatch(ctx context.Context, q Q) (R, error) {
+	var result R
+	rs, err := qb.b.Dispatch(ctx, q)
+	if rs == nil {
+		return result, err
+	}
+	result, ok := rs.(R)
+	if !ok {
+		return result, fmt.Errorf("%w: %s returned %T", ErrUnexpectedQueryResult, q.Name(), rs)
+	}
+	return result, err
+}
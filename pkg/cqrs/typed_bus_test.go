@@ -0,0 +1,147 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type addUserCmd struct {
+	userName string
+}
+
+func (addUserCmd) Name() string { return "add_user" }
+
+type getUserQuery struct {
+	id uuid.UUID
+}
+
+func (getUserQuery) Name() string { return "get_user" }
+
+type userDTO struct {
+	ID uuid.UUID
+}
+
+type removeUserCmd struct {
+	id uuid.UUID
+}
+
+func (*removeUserCmd) Name() string { return "remove_user" }
+
+func TestCommandBus(t *testing.T) {
+	t.Run(`Given command buses of several command types backed by the same bus,
+		when commands are dispatched,
+		then each handler receives its concrete command and its events are returned`, func(t *testing.T) {
+		var (
+			b         = bus.New()
+			addBus    = cqrs.NewCommandBus[addUserCmd](b)
+			removeBus = cqrs.NewCommandBus[*removeUserCmd](b)
+			add       = addUserCmd{userName: "Bond"}
+			remove    = &removeUserCmd{id: uuid.New()}
+			ev        = events.NewEventBasic(uuid.New(), "user_added", nil)
+			added     addUserCmd
+			removed   *removeUserCmd
+		)
+		addBus.Register("add_user", cqrs.TypedCommandHandlerFunc[addUserCmd](func(_ context.Context, c addUserCmd) ([]events.Event, error) {
+			added = c
+			return []events.Event{ev}, nil
+		}))
+		removeBus.Register("remove_user", cqrs.TypedCommandHandlerFunc[*removeUserCmd](func(_ context.Context, c *removeUserCmd) ([]events.Event, error) {
+			removed = c
+			return nil, nil
+		}))
+		require.ElementsMatch(t, []string{"add_user", "remove_user"}, b.Handlers())
+
+		evs, err := addBus.Dispatch(context.Background(), add)
+		require.NoError(t, err)
+		require.Equal(t, add, added)
+		require.Equal(t, []events.Event{ev}, evs)
+
+		evs, err = removeBus.Dispatch(context.Background(), remove)
+		require.NoError(t, err)
+		require.Equal(t, remove, removed)
+		require.Empty(t, evs)
+	})
+
+	t.Run(`Given a command bus of an interface type,
+		when a handler is registered and a command is dispatched,
+		then it's handled`, func(t *testing.T) {
+		var handled cqrs.Command
+		cb := cqrs.NewCommandBus[cqrs.Command](bus.New())
+		cb.Register("add_user", cqrs.TypedCommandHandlerFunc[cqrs.Command](func(_ context.Context, c cqrs.Command) ([]events.Event, error) {
+			handled = c
+			return nil, nil
+		}))
+
+		_, err := cb.Dispatch(context.Background(), addUserCmd{userName: "Bond"})
+		require.NoError(t, err)
+		require.Equal(t, addUserCmd{userName: "Bond"}, handled)
+	})
+
+	t.Run(`Given a bus without handlers,
+		when a command is dispatched,
+		then an error is returned`, func(t *testing.T) {
+		_, err := cqrs.NewCommandBus[addUserCmd](bus.New()).Dispatch(context.Background(), addUserCmd{})
+		require.ErrorIs(t, err, bus.ErrNotDispatchable)
+	})
+}
+
+func TestBusTypedChHandler(t *testing.T) {
+	t.Run(`Given a typed command handler registered into a generic bus,
+		when a dispatchable of another type is dispatched,
+		then an unexpected command error is returned`, func(t *testing.T) {
+		b := bus.New()
+		b.Register("add_user", cqrs.BusTypedChHandler[addUserCmd](cqrs.TypedCommandHandlerFunc[addUserCmd](
+			func(_ context.Context, _ addUserCmd) ([]events.Event, error) {
+				return nil, nil
+			})))
+
+		_, err := b.Dispatch(context.Background(), &CommandMock{NameFunc: func() string { return "add_user" }})
+		require.ErrorIs(t, err, cqrs.ErrUnexpectedCommand)
+	})
+}
+
+func TestQueryBus(t *testing.T) {
+	t.Run(`Given a query bus,
+		when a query is dispatched,
+		then the typed result is returned`, func(t *testing.T) {
+		var (
+			b = bus.New()
+			q = getUserQuery{id: uuid.New()}
+		)
+		cqrs.NewQueryBus[getUserQuery, userDTO](b).Register("get_user", cqrs.TypedQueryHandlerFunc[getUserQuery, userDTO](func(_ context.Context, q getUserQuery) (userDTO, error) {
+			return userDTO{ID: q.id}, nil
+		}))
+
+		rs, err := cqrs.NewQueryBus[getUserQuery, userDTO](b).Dispatch(context.Background(), q)
+		require.NoError(t, err)
+		require.Equal(t, userDTO{ID: q.id}, rs)
+
+		_, err = cqrs.NewQueryBus[getUserQuery, *userDTO](b).Dispatch(context.Background(), q)
+		require.ErrorIs(t, err, cqrs.ErrUnexpectedQueryResult)
+	})
+
+	t.Run(`Given a typed query handler that fails,
+		when a query is dispatched,
+		then the error and a zero result are returned`, func(t *testing.T) {
+		var (
+			qb        = cqrs.NewQueryBus[getUserQuery, *userDTO](bus.New())
+			randomErr = errors.New("")
+			q         = getUserQuery{id: uuid.New()}
+		)
+		qb.Register("get_user", cqrs.TypedQueryHandlerFunc[getUserQuery, *userDTO](func(_ context.Context, _ getUserQuery) (*userDTO, error) {
+			return nil, randomErr
+		}))
+
+		rs, err := qb.Dispatch(context.Background(), q)
+		require.ErrorIs(t, err, randomErr)
+		require.Nil(t, rs)
+	})
+}
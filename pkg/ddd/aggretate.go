@@ -40,3 +40,20 @@ func (ab *AggregateBasic) Events() []events.Event {
 	ab.events = []events.Event{}
 	return e
 }
+
+// PendingEvents returns the recorded events, without clearing them
+func (ab *AggregateBasic) PendingEvents() []events.Event {
+	ab.mux.Lock()
+	defer ab.mux.Unlock()
+	return append([]events.Event(nil), ab.events...)
+}
+
+// ClearEvents removes the first n recorded events, once they've been saved
+func (ab *AggregateBasic) ClearEvents(n int) {
+	ab.mux.Lock()
+	defer ab.mux.Unlock()
+	if n > len(ab.events) {
+		n = len(ab.events)
+	}
+	ab.events = ab.events[n:]
+}
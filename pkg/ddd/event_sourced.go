@@ -0,0 +1,113 @@
+package ddd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/snapshot"
+)
+
+// ErrNoApplyFunc is returned when an event without an apply function is applied to an event-sourced aggregate
+var ErrNoApplyFunc = errors.New("no apply function for event")
+
+// ApplyFunc mutates the aggregate state from an event
+type ApplyFunc func(e events.Event)
+
+// EventSourcedAggregate is an aggregate whose state is only mutated by applying events to it.
+// Embed it into the aggregate, and register an apply function for each one of its events with On.
+// Then, Apply changes the aggregate state and records the event, and Rehydrate rebuilds the state from its history.
+type EventSourcedAggregate struct {
+	AggregateBasic
+	version int
+	apply   map[string]ApplyFunc
+	// latest is the latest snapshot of the aggregate, without its state
+	latest snapshot.Snapshot
+}
+
+// NewEventSourcedAggregate is a constructor
+func NewEventSourcedAggregate(ID uuid.UUID) EventSourcedAggregate {
+	return EventSourcedAggregate{
+		AggregateBasic: NewAggregateBasic(ID),
+		apply:          make(map[string]ApplyFunc),
+	}
+}
+
+// On registers the apply function of the events with the given name.
+// It's meant to be called when the aggregate is built, before applying any event.
+func (a *EventSourcedAggregate) On(name string, f ApplyFunc) {
+	a.apply[name] = f
+}
+
+// Version returns the version of the aggregate: the stored version of its last rehydrated event,
+// plus the number of events applied but not saved yet
+func (a *EventSourcedAggregate) Version() int {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	return a.version
+}
+
+// Apply mutates the aggregate state with the event, and records it to be saved.
+// If the event has metadata, like the ones that embed events.EventBasic, its aggregate version is set.
+func (a *EventSourcedAggregate) Apply(e events.Event) error {
+	version := a.Version() + 1
+	e, _ = events.UpdateMetadata(e, func(m events.Metadata) events.Metadata {
+		m.AggregateVersion = version
+		return m
+	})
+	if err := a.applyEvent(e); err != nil {
+		return err
+	}
+	a.RecordEvent(e)
+	return nil
+}
+
+// Rehydrate replays the history of the aggregate. The events are applied, but not recorded.
+// If the events have an aggregate version, like the ones loaded from eventstore.SQLStore, the aggregate
+// takes it, so several events upcast from the same stored one don't move its version further than the stored one.
+func (a *EventSourcedAggregate) Rehydrate(evs ...events.Event) error {
+	for _, e := range evs {
+		if err := a.applyEvent(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *EventSourcedAggregate) applyEvent(e events.Event) error {
+	f, ok := a.apply[e.Name()]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoApplyFunc, e.Name())
+	}
+	f(e)
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if ee, ok := e.(events.Enveloped); ok && ee.Metadata().AggregateVersion > 0 {
+		a.version = ee.Metadata().AggregateVersion
+		return nil
+	}
+	a.version++
+	return nil
+}
+
+func (a *EventSourcedAggregate) restoredFrom(sn snapshot.Snapshot) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	a.version = sn.Version
+	sn.State = nil
+	a.latest = sn
+}
+
+func (a *EventSourcedAggregate) snapshotted(sn snapshot.Snapshot) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	sn.State = nil
+	a.latest = sn
+}
+
+func (a *EventSourcedAggregate) latestSnapshot() snapshot.Snapshot {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	return a.latest
+}
@@ -0,0 +1,90 @@
+package ddd_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/theskyinflames/cqrs-eda/pkg/ddd"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+)
+
+type account struct {
+	ddd.EventSourcedAggregate
+	balance int
+	applied int
+}
+
+type deposited struct {
+	TestEvent
+	amount int
+}
+
+func newAccount(id uuid.UUID) *account {
+	a := &account{EventSourcedAggregate: ddd.NewEventSourcedAggregate(id)}
+	a.On("deposited", func(e events.Event) {
+		a.balance += e.(deposited).amount
+		a.applied++
+	})
+	return a
+}
+
+func (a *account) Deposit(amount int) error {
+	return a.Apply(deposited{TestEvent: TestEvent{id: a.ID(), name: "deposited"}, amount: amount})
+}
+
+func (a *account) SnapshotState() ([]byte, error) {
+	return []byte(strconv.Itoa(a.balance)), nil
+}
+
+func (a *account) RestoreState(state []byte) error {
+	var err error
+	a.balance, err = strconv.Atoi(string(state))
+	return err
+}
+
+func TestEventSourcedAggregate(t *testing.T) {
+	t.Run(`Given an event-sourced aggregate, when events are applied, then its state and version change and the events are recorded`, func(t *testing.T) {
+		a := newAccount(uuid.New())
+		require.NoError(t, a.Deposit(10))
+		require.NoError(t, a.Deposit(5))
+
+		require.Equal(t, 15, a.balance)
+		require.Equal(t, 2, a.Version())
+		require.Len(t, a.Events(), 2)
+	})
+
+	t.Run(`Given an event-sourced aggregate, when events with metadata are applied, then their aggregate version is set`, func(t *testing.T) {
+		a := ddd.NewEventSourcedAggregate(uuid.New())
+		a.On("renamed", func(events.Event) {})
+		require.NoError(t, a.Apply(events.NewEventBasic(a.ID(), "renamed", nil)))
+		require.NoError(t, a.Apply(events.NewEventBasic(a.ID(), "renamed", nil)))
+
+		evs := a.Events()
+		require.Len(t, evs, 2)
+		require.Equal(t, 1, evs[0].(events.Enveloped).Metadata().AggregateVersion)
+		require.Equal(t, 2, evs[1].(events.Enveloped).Metadata().AggregateVersion)
+	})
+
+	t.Run(`Given an event-sourced aggregate, when it's rehydrated, then its state is rebuilt and no events are recorded`, func(t *testing.T) {
+		id := uuid.New()
+		a := newAccount(id)
+		require.NoError(t, a.Rehydrate(
+			deposited{TestEvent: TestEvent{id: id, name: "deposited"}, amount: 10},
+			deposited{TestEvent: TestEvent{id: id, name: "deposited"}, amount: 20},
+		))
+
+		require.Equal(t, 30, a.balance)
+		require.Equal(t, 2, a.Version())
+		require.Empty(t, a.Events())
+	})
+
+	t.Run(`Given an event-sourced aggregate, when an event without apply function is applied, then an error is returned`, func(t *testing.T) {
+		a := newAccount(uuid.New())
+		err := a.Apply(TestEvent{name: "withdrawn"})
+		require.ErrorIs(t, err, ddd.ErrNoApplyFunc)
+		require.Equal(t, 0, a.Version())
+		require.Empty(t, a.Events())
+	})
+}
@@ -0,0 +1,188 @@
+package ddd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+	"github.com/theskyinflames/cqrs-eda/pkg/snapshot"
+)
+
+// ErrAggregateNotFound is returned when loading an aggregate without events
+var ErrAggregateNotFound = errors.New("aggregate not found")
+
+// EventSourced is an aggregate built from its events, like the ones that embed EventSourcedAggregate
+type EventSourced interface {
+	ID() uuid.UUID
+	Version() int
+	PendingEvents() []events.Event
+	ClearEvents(n int)
+	Rehydrate(evs ...events.Event) error
+}
+
+// Snapshotter is an event-sourced aggregate whose state can be snapshotted.
+// Embed EventSourcedAggregate into the aggregate to implement the unexported methods.
+type Snapshotter interface {
+	EventSourced
+	// SnapshotState encodes the aggregate state
+	SnapshotState() ([]byte, error)
+	// RestoreState sets the aggregate state from an encoded one
+	RestoreState(state []byte) error
+
+	restoredFrom(sn snapshot.Snapshot)
+	snapshotted(sn snapshot.Snapshot)
+	latestSnapshot() snapshot.Snapshot
+}
+
+// RepositoryOpt is an option for the repository constructor
+type RepositoryOpt[A EventSourced] func(*Repository[A])
+
+// WithPublisher makes the repository publish the events to the dispatcher, like a bus, once they've been saved
+func WithPublisher[A EventSourced](p bus.Dispatcher) RepositoryOpt[A] {
+	return func(r *Repository[A]) {
+		r.publisher = p
+	}
+}
+
+// WithSnapshots makes the repository load the aggregates from their latest snapshot, replaying only the
+// subsequent events, and take a snapshot when the policy says so. The snapshots taken with a schema version
+// other than schemaVersion are ignored, so bump it when the encoding of the aggregate state changes.
+// The aggregates must implement Snapshotter, otherwise the snapshots are not used.
+func WithSnapshots[A EventSourced](store snapshot.Store, policy snapshot.Policy, schemaVersion int) RepositoryOpt[A] {
+	return func(r *Repository[A]) {
+		r.snapshots = store
+		r.policy = policy
+		r.schemaVersion = schemaVersion
+	}
+}
+
+// WithLogger sets the logger of the errors taking snapshots. By default, it's the standard logger.
+func WithLogger[A EventSourced](l cqrs.Logger) RepositoryOpt[A] {
+	return func(r *Repository[A]) {
+		r.logger = l
+	}
+}
+
+// Repository loads and saves event-sourced aggregates from an event store
+type Repository[A EventSourced] struct {
+	store     eventstore.EventStore
+	factory   func(id uuid.UUID) A
+	publisher bus.Dispatcher
+
+	snapshots     snapshot.Store
+	policy        snapshot.Policy
+	schemaVersion int
+	logger        cqrs.Logger
+}
+
+// NewRepository is a constructor. The factory returns an empty aggregate with the given ID, ready to be rehydrated.
+func NewRepository[A EventSourced](store eventstore.EventStore, factory func(id uuid.UUID) A, opts ...RepositoryOpt[A]) Repository[A] {
+	r := Repository[A]{
+		store:   store,
+		factory: factory,
+		logger:  log.Default(),
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// Load returns the aggregate rehydrated from its events
+func (r Repository[A]) Load(ctx context.Context, id uuid.UUID) (A, error) {
+	var zero A
+	a := r.factory(id)
+	restored, err := r.restore(ctx, a)
+	if err != nil {
+		return zero, err
+	}
+	evs, err := r.store.Load(ctx, id, a.Version())
+	if err != nil {
+		return zero, err
+	}
+	if len(evs) == 0 && !restored {
+		return zero, fmt.Errorf("%w: %s", ErrAggregateNotFound, id)
+	}
+	if err := a.Rehydrate(evs...); err != nil {
+		return zero, err
+	}
+	return a, nil
+}
+
+// restore sets the aggregate state from its latest snapshot, if any. It returns whether it has been restored.
+func (r Repository[A]) restore(ctx context.Context, a A) (bool, error) {
+	s, ok := interface{}(a).(Snapshotter)
+	if !ok || r.snapshots == nil {
+		return false, nil
+	}
+	sn, err := r.snapshots.Latest(ctx, a.ID())
+	if errors.Is(err, snapshot.ErrNotFound) || (err == nil && sn.SchemaVersion != r.schemaVersion) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := s.RestoreState(sn.State); err != nil {
+		return false, fmt.Errorf("restoring snapshot of aggregate %s: %w", a.ID(), err)
+	}
+	s.restoredFrom(sn)
+	return true, nil
+}
+
+// Save appends the pending events of the aggregate to the event store, and publishes them if there is a publisher.
+// The pending events are cleared once they've been appended, so they're kept if appending them fails.
+// If the aggregate has been changed since it was loaded, an eventstore.ErrConcurrencyConflict is returned,
+// and the aggregate should be loaded again.
+func (r Repository[A]) Save(ctx context.Context, a A) error {
+	evs := a.PendingEvents()
+	if len(evs) == 0 {
+		return nil
+	}
+	if err := r.store.Append(ctx, a.ID(), a.Version()-len(evs), evs...); err != nil {
+		return err
+	}
+	a.ClearEvents(len(evs))
+	r.snapshot(ctx, a, len(evs))
+	if r.publisher == nil {
+		return nil
+	}
+	for _, e := range evs {
+		if _, err := r.publisher.Dispatch(ctx, e); err != nil {
+			return fmt.Errorf("publishing event %s: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+// snapshot takes a snapshot of the aggregate if the policy says so. Since the events have already been saved,
+// the errors are only logged (see WithLogger).
+func (r Repository[A]) snapshot(ctx context.Context, a A, saved int) {
+	s, ok := interface{}(a).(Snapshotter)
+	if !ok || r.snapshots == nil || !r.policy(a.Version(), saved, s.latestSnapshot()) {
+		return
+	}
+	state, err := s.SnapshotState()
+	if err != nil {
+		r.logger.Printf("snapshot of aggregate %s: %s", a.ID(), err)
+		return
+	}
+	sn := snapshot.Snapshot{
+		AggregateID:   a.ID(),
+		Version:       a.Version(),
+		SchemaVersion: r.schemaVersion,
+		State:         state,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := r.snapshots.Save(ctx, sn); err != nil {
+		r.logger.Printf("snapshot of aggregate %s: %s", a.ID(), err)
+		return
+	}
+	s.snapshotted(sn)
+}
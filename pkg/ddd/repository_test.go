@@ -0,0 +1,226 @@
+package ddd_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/ddd"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+	"github.com/theskyinflames/cqrs-eda/pkg/snapshot"
+)
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run(`Given a repository, when an aggregate is saved and loaded, then it's rehydrated from its events`, func(t *testing.T) {
+		var (
+			published []bus.Dispatchable
+			b         = bus.New()
+		)
+		b.Register("deposited", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			published = append(published, d)
+			return nil, nil
+		})
+		r := ddd.NewRepository(eventstore.NewMemoryStore(), newAccount, ddd.WithPublisher[*account](b))
+
+		a := newAccount(uuid.New())
+		require.NoError(t, a.Deposit(10))
+		require.NoError(t, a.Deposit(20))
+		require.NoError(t, r.Save(ctx, a))
+		require.Len(t, published, 2)
+
+		require.NoError(t, a.Deposit(5))
+		require.NoError(t, r.Save(ctx, a))
+		require.Len(t, published, 3)
+
+		loaded, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+		require.Equal(t, 35, loaded.balance)
+		require.Equal(t, 3, loaded.Version())
+	})
+
+	t.Run(`Given a repository, when a not existing aggregate is loaded, then an error is returned`, func(t *testing.T) {
+		r := ddd.NewRepository(eventstore.NewMemoryStore(), newAccount)
+		_, err := r.Load(ctx, uuid.New())
+		require.ErrorIs(t, err, ddd.ErrAggregateNotFound)
+	})
+
+	t.Run(`Given an aggregate loaded twice, when both copies are saved, then the last one gets a concurrency conflict`, func(t *testing.T) {
+		r := ddd.NewRepository(eventstore.NewMemoryStore(), newAccount)
+		a := newAccount(uuid.New())
+		require.NoError(t, a.Deposit(10))
+		require.NoError(t, r.Save(ctx, a))
+
+		a1, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+		a2, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+
+		require.NoError(t, a1.Deposit(1))
+		require.NoError(t, a2.Deposit(2))
+		require.NoError(t, r.Save(ctx, a1))
+		require.ErrorIs(t, r.Save(ctx, a2), eventstore.ErrConcurrencyConflict)
+	})
+
+	t.Run(`Given a stored event upcast to several ones, when the aggregate is loaded and saved, then its stored version is expected`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		upcasters := events.NewUpcasters()
+		upcasters.Register("entries_added", 1, func(e events.RawEvent) ([]events.RawEvent, error) {
+			e.Name = "entry_added"
+			return []events.RawEvent{e, e}, nil
+		})
+		registry := events.NewRegistry(events.JSONEncoder{}, events.WithUpcasters(upcasters))
+		registry.Register(events.NewEventBasic(uuid.Nil, "entry_added", nil))
+
+		id := uuid.New()
+		payload, err := registry.Encode(events.NewEventBasic(id, "entries_added", nil))
+		require.NoError(t, err)
+		mock.ExpectQuery(`SELECT version, name, payload FROM events`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "payload"}).AddRow(1, "entries_added", payload))
+
+		r := ddd.NewRepository(eventstore.NewSQLStore(db, registry), newLedger)
+		l, err := r.Load(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, 2, l.entries)
+		require.Equal(t, 1, l.Version())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM events`).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectExec(`INSERT INTO events`).
+			WithArgs(id.String(), 2, "entry_added", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, l.Apply(events.NewEventBasic(id, "entry_added", nil)))
+		require.NoError(t, r.Save(ctx, l))
+		require.Equal(t, 2, l.Version())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a store that fails to append, when an aggregate is saved, then its events are kept until they're appended`, func(t *testing.T) {
+		var (
+			store = &failingStore{EventStore: eventstore.NewMemoryStore(), fails: 1}
+			r     = ddd.NewRepository(store, newAccount)
+			a     = newAccount(uuid.New())
+		)
+		require.NoError(t, a.Deposit(10))
+		require.Error(t, r.Save(ctx, a))
+		require.Len(t, a.PendingEvents(), 1)
+
+		require.NoError(t, r.Save(ctx, a))
+		require.Empty(t, a.PendingEvents())
+
+		loaded, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+		require.Equal(t, 10, loaded.balance)
+	})
+
+	t.Run(`Given a repository with snapshots, when an aggregate is loaded, then it's restored from its latest snapshot and the subsequent events`, func(t *testing.T) {
+		var (
+			snapshots = snapshot.NewMemoryStore()
+			r         = ddd.NewRepository(eventstore.NewMemoryStore(), newAccount, ddd.WithSnapshots[*account](snapshots, snapshot.EveryN(3), 1))
+			a         = newAccount(uuid.New())
+		)
+		for i := 1; i <= 4; i++ {
+			require.NoError(t, a.Deposit(i))
+			require.NoError(t, r.Save(ctx, a))
+		}
+
+		sn, err := snapshots.Latest(ctx, a.ID())
+		require.NoError(t, err)
+		require.Equal(t, 3, sn.Version)
+
+		loaded, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+		require.Equal(t, 10, loaded.balance)
+		require.Equal(t, 4, loaded.Version())
+		require.Equal(t, 1, loaded.applied)
+	})
+
+	t.Run(`Given a repository with snapshots, when the latest snapshot has an outdated schema version, then it's ignored`, func(t *testing.T) {
+		var (
+			store     = eventstore.NewMemoryStore()
+			snapshots = snapshot.NewMemoryStore()
+			a         = newAccount(uuid.New())
+		)
+		r := ddd.NewRepository(store, newAccount, ddd.WithSnapshots[*account](snapshots, snapshot.EveryN(1), 1))
+		require.NoError(t, a.Deposit(10))
+		require.NoError(t, r.Save(ctx, a))
+
+		r = ddd.NewRepository(store, newAccount, ddd.WithSnapshots[*account](snapshots, snapshot.EveryN(1), 2))
+		loaded, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+		require.Equal(t, 10, loaded.balance)
+		require.Equal(t, 1, loaded.applied)
+		require.Equal(t, 1, loaded.Version())
+	})
+
+	t.Run(`Given a repository with snapshots, when a snapshot fails to be saved, then the error is logged and the aggregate is saved`, func(t *testing.T) {
+		var (
+			store     = eventstore.NewMemoryStore()
+			snapshots = failingSnapshots{Store: snapshot.NewMemoryStore()}
+			l         = &logger{}
+			a         = newAccount(uuid.New())
+		)
+		r := ddd.NewRepository(store, newAccount, ddd.WithSnapshots[*account](snapshots, snapshot.EveryN(1), 1), ddd.WithLogger[*account](l))
+		require.NoError(t, a.Deposit(10))
+		require.NoError(t, r.Save(ctx, a))
+		require.Len(t, l.lines, 1)
+		require.Contains(t, l.lines[0], "save failed")
+
+		loaded, err := r.Load(ctx, a.ID())
+		require.NoError(t, err)
+		require.Equal(t, 10, loaded.balance)
+	})
+}
+
+type failingSnapshots struct {
+	snapshot.Store
+}
+
+func (failingSnapshots) Save(context.Context, snapshot.Snapshot) error {
+	return errors.New("save failed")
+}
+
+type logger struct {
+	lines []string
+}
+
+func (l *logger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+type failingStore struct {
+	eventstore.EventStore
+	fails int
+}
+
+func (s *failingStore) Append(ctx context.Context, aggregateID uuid.UUID, expectedVersion int, evs ...events.Event) error {
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("append failed")
+	}
+	return s.EventStore.Append(ctx, aggregateID, expectedVersion, evs...)
+}
+
+// ledger is an aggregate that counts its entries
+type ledger struct {
+	ddd.EventSourcedAggregate
+	entries int
+}
+
+func newLedger(id uuid.UUID) *ledger {
+	l := &ledger{EventSourcedAggregate: ddd.NewEventSourcedAggregate(id)}
+	l.On("entry_added", func(events.Event) { l.entries++ })
+	return l
+}
@@ -0,0 +1,145 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/retry"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a dead letter is not in the store
+var ErrNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a dispatchable whose handling failed.
+// The error is kept as a string, so it can be persisted.
+type DeadLetter struct {
+	ID            uuid.UUID
+	Dispatchable  bus.Dispatchable
+	Handler       string
+	Err           string
+	Attempts      int
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+}
+
+// Store keeps the dead letters
+type Store interface {
+	// Save adds a dead letter, or replaces it if there is already one with the same ID
+	Save(ctx context.Context, dl DeadLetter) error
+	// Get returns the dead letter with the given ID, or ErrNotFound
+	Get(ctx context.Context, id uuid.UUID) (DeadLetter, error)
+	// List returns all the dead letters, the oldest first
+	List(ctx context.Context) ([]DeadLetter, error)
+	// Delete removes the dead letter with the given ID, or returns ErrNotFound
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type deadLetterCtxKey struct{}
+
+// detachedCtx keeps the values of its parent, but it's never done.
+// It allows saving a dead letter when the handler failed because its context expired.
+type detachedCtx struct {
+	context.Context
+}
+
+func (detachedCtx) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedCtx) Done() <-chan struct{} { return nil }
+
+func (detachedCtx) Err() error { return nil }
+
+// failed returns the dead letter for a failed handling. If it's a redispatch, the original dead letter is updated.
+func failed(ctx context.Context, d bus.Dispatchable, handler string, err error) DeadLetter {
+	attempts := 1
+	var retryErr retry.Error
+	if errors.As(err, &retryErr) {
+		attempts = retryErr.Attempts
+	}
+	now := time.Now()
+
+	dl, ok := ctx.Value(deadLetterCtxKey{}).(DeadLetter)
+	if !ok {
+		return DeadLetter{
+			ID:            uuid.New(),
+			Dispatchable:  d,
+			Handler:       handler,
+			Err:           err.Error(),
+			Attempts:      attempts,
+			FirstFailedAt: now,
+			LastFailedAt:  now,
+		}
+	}
+	dl.Err = err.Error()
+	dl.Attempts += attempts
+	dl.LastFailedAt = now
+	return dl
+}
+
+// BusMw is a bus middleware that saves a dead letter when the wrapped handler fails, including when it times out.
+// The handler is identified by the given name, or by the dispatchable name if it's empty.
+// The handler error is returned as is. If the dead letter can't be saved, both errors are returned.
+func BusMw(s Store, handler string) bus.Middleware {
+	return func(h bus.Handler) bus.Handler {
+		return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+			rs, err := h(ctx, d)
+			if err == nil {
+				return rs, nil
+			}
+			name := handler
+			if name == "" {
+				name = d.Name()
+			}
+			if saveErr := s.Save(detachedCtx{ctx}, failed(ctx, d, name, err)); saveErr != nil {
+				return rs, fmt.Errorf("%w (saving dead letter: %s)", err, saveErr.Error())
+			}
+			return rs, err
+		}
+	}
+}
+
+// Redispatch dispatches again the dispatchable of a dead letter. If it succeeds, the dead letter is removed.
+// Otherwise, it's updated with the new error and attempts.
+func Redispatch(ctx context.Context, s Store, b bus.Dispatcher, id uuid.UUID) (interface{}, error) {
+	dl, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dlCtx := context.WithValue(ctx, deadLetterCtxKey{}, dl)
+	rs, err := b.Dispatch(dlCtx, dl.Dispatchable)
+	if err != nil {
+		// If the bus handler is wrapped by BusMw, the dead letter has already been updated
+		if updated, getErr := s.Get(ctx, id); getErr == nil && updated.Attempts > dl.Attempts {
+			return rs, err
+		}
+		if saveErr := s.Save(ctx, failed(dlCtx, dl.Dispatchable, dl.Handler, err)); saveErr != nil {
+			return rs, fmt.Errorf("%w (saving dead letter: %s)", err, saveErr.Error())
+		}
+		return rs, err
+	}
+	return rs, s.Delete(ctx, id)
+}
+
+// RedispatchAll redispatches all the dead letters, the oldest first.
+// It returns the IDs of the ones that failed again.
+func RedispatchAll(ctx context.Context, s Store, b bus.Dispatcher) ([]uuid.UUID, error) {
+	dls, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var failedIDs []uuid.UUID
+	for _, dl := range dls {
+		if err := ctx.Err(); err != nil {
+			return failedIDs, err
+		}
+		if _, err := Redispatch(ctx, s, b, dl.ID); err != nil {
+			failedIDs = append(failedIDs, dl.ID)
+		}
+	}
+	return failedIDs, nil
+}
@@ -0,0 +1,172 @@
+package deadletter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/deadletter"
+	"github.com/theskyinflames/cqrs-eda/pkg/retry"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusMw(t *testing.T) {
+	var (
+		randomErr = errors.New("random error")
+		cmd       = addUserCmd{UserName: "Bond"}
+	)
+
+	t.Run(`Given a bus handler wrapped by the dead letter middleware,
+		when it fails,
+		then a dead letter is saved and the error returned`, func(t *testing.T) {
+		s := deadletter.NewMemoryStore()
+		h := deadletter.BusMw(s, "")(func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			return nil, randomErr
+		})
+
+		_, err := h(context.Background(), cmd)
+		require.ErrorIs(t, err, randomErr)
+
+		dls, err := s.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		require.Equal(t, cmd, dls[0].Dispatchable)
+		require.Equal(t, cmd.Name(), dls[0].Handler)
+		require.Equal(t, randomErr.Error(), dls[0].Err)
+		require.Equal(t, 1, dls[0].Attempts)
+		require.False(t, dls[0].FirstFailedAt.IsZero())
+	})
+
+	t.Run(`Given a retried bus handler wrapped by the dead letter middleware,
+		when all its attempts fail,
+		then the attempts are kept in the dead letter`, func(t *testing.T) {
+		s := deadletter.NewMemoryStore()
+		h := bus.MultiMiddleware(
+			retry.BusMw(retry.NewPolicy(3, retry.WithBackoff(time.Millisecond, time.Millisecond, 1))),
+			deadletter.BusMw(s, "users projection"),
+		)(func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			return nil, randomErr
+		})
+
+		_, err := h(context.Background(), cmd)
+		require.ErrorIs(t, err, randomErr)
+
+		dls, err := s.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		require.Equal(t, "users projection", dls[0].Handler)
+		require.Equal(t, 3, dls[0].Attempts)
+	})
+
+	t.Run(`Given a concurrent bus with the dead letter middleware,
+		when a handler times out,
+		then a dead letter is saved`, func(t *testing.T) {
+		s := deadletter.NewMemoryStore()
+		cbus := bus.NewConcurrentBus(time.Millisecond, 1)
+		cbus.Use(deadletter.BusMw(s, ""))
+		cbus.Register(cmd.Name(), func(ctx context.Context, _ bus.Dispatchable) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cbus.Run(ctx)
+
+		rs := <-cbus.Dispatch(ctx, cmd)
+		require.ErrorIs(t, rs.Err, context.DeadlineExceeded)
+
+		dls, err := s.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		require.Equal(t, context.DeadlineExceeded.Error(), dls[0].Err)
+	})
+}
+
+func TestRedispatch(t *testing.T) {
+	var (
+		randomErr = errors.New("random error")
+		cmd       = addUserCmd{UserName: "Bond"}
+	)
+
+	t.Run(`Given a dead letter of a bus with the dead letter middleware,
+		when it's redispatched and fails again,
+		then the same dead letter is updated`, func(t *testing.T) {
+		var (
+			ctx   = context.Background()
+			s     = deadletter.NewMemoryStore()
+			b     = bus.New()
+			calls int
+		)
+		b.Use(deadletter.BusMw(s, ""))
+		b.Register(cmd.Name(), func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			calls++
+			if calls < 3 {
+				return nil, randomErr
+			}
+			return "a response", nil
+		})
+
+		_, err := b.Dispatch(ctx, cmd)
+		require.ErrorIs(t, err, randomErr)
+		dls, err := s.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		id := dls[0].ID
+
+		_, err = deadletter.Redispatch(ctx, s, b, id)
+		require.ErrorIs(t, err, randomErr)
+		dls, err = s.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		require.Equal(t, id, dls[0].ID)
+		require.Equal(t, 2, dls[0].Attempts)
+
+		rs, err := deadletter.Redispatch(ctx, s, b, id)
+		require.NoError(t, err)
+		require.Equal(t, "a response", rs)
+		_, err = s.Get(ctx, id)
+		require.ErrorIs(t, err, deadletter.ErrNotFound)
+	})
+
+	t.Run(`Given dead letters of a concurrent bus,
+		when all of them are redispatched,
+		then the ones that fail again are returned`, func(t *testing.T) {
+		var (
+			ctx    = context.Background()
+			s      = deadletter.NewMemoryStore()
+			failed = addUserCmd{UserName: "Blofeld"}
+			cbus   = bus.NewConcurrentBus(time.Second, 1)
+		)
+		cbus.Register(cmd.Name(), func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			if d == failed {
+				return nil, randomErr
+			}
+			return nil, nil
+		})
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go cbus.Run(runCtx)
+
+		h := deadletter.BusMw(s, "")(handlerFixture(randomErr))
+		_, _ = h(ctx, cmd)
+		_, _ = h(ctx, failed)
+
+		failedIDs, err := deadletter.RedispatchAll(ctx, s, bus.Await(cbus))
+		require.NoError(t, err)
+		require.Len(t, failedIDs, 1)
+
+		dl, err := s.Get(ctx, failedIDs[0])
+		require.NoError(t, err)
+		require.Equal(t, failed, dl.Dispatchable)
+		require.Equal(t, 2, dl.Attempts)
+	})
+}
+
+func handlerFixture(err error) bus.Handler {
+	return func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+		return nil, err
+	}
+}
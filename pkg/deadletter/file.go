@@ -0,0 +1,156 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/google/uuid"
+)
+
+// Codec encodes and decodes dispatchables to be persisted. codec.Registry implements it.
+type Codec interface {
+	Encode(d bus.Dispatchable) ([]byte, error)
+	Decode(name string, data []byte) (bus.Dispatchable, error)
+}
+
+type fileDeadLetter struct {
+	ID            uuid.UUID       `json:"id"`
+	Name          string          `json:"name"`
+	Dispatchable  json.RawMessage `json:"dispatchable"`
+	Handler       string          `json:"handler"`
+	Err           string          `json:"err"`
+	Attempts      int             `json:"attempts"`
+	FirstFailedAt time.Time       `json:"first_failed_at"`
+	LastFailedAt  time.Time       `json:"last_failed_at"`
+}
+
+// FileStore is a dead letters store that keeps each dead letter in a JSON file of a directory
+type FileStore struct {
+	mux   *sync.RWMutex
+	dir   string
+	codec Codec
+}
+
+// NewFileStore is a constructor. The directory is created if it doesn't exist. The codec must encode
+// the dispatchables as JSON, like codec.Registry with events.JSONEncoder.
+func NewFileStore(dir string, codec Codec) (FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return FileStore{}, err
+	}
+	return FileStore{
+		mux:   &sync.RWMutex{},
+		dir:   dir,
+		codec: codec,
+	}, nil
+}
+
+func (s FileStore) path(id uuid.UUID) string {
+	return filepath.Join(s.dir, id.String()+".json")
+}
+
+// Save implements the Store interface
+func (s FileStore) Save(_ context.Context, dl DeadLetter) error {
+	b, err := s.codec.Encode(dl.Dispatchable)
+	if err != nil {
+		return err
+	}
+	fdl, err := json.Marshal(fileDeadLetter{
+		ID:            dl.ID,
+		Name:          dl.Dispatchable.Name(),
+		Dispatchable:  b,
+		Handler:       dl.Handler,
+		Err:           dl.Err,
+		Attempts:      dl.Attempts,
+		FirstFailedAt: dl.FirstFailedAt,
+		LastFailedAt:  dl.LastFailedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	// Write and rename, so a crash doesn't leave a partial dead letter
+	tmp := s.path(dl.ID) + ".tmp"
+	if err := os.WriteFile(tmp, fdl, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(dl.ID))
+}
+
+// Get implements the Store interface
+func (s FileStore) Get(_ context.Context, id uuid.UUID) (DeadLetter, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.read(s.path(id))
+}
+
+func (s FileStore) read(path string) (DeadLetter, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DeadLetter{}, ErrNotFound
+	}
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	var fdl fileDeadLetter
+	if err := json.Unmarshal(b, &fdl); err != nil {
+		return DeadLetter{}, fmt.Errorf("%s: %w", path, err)
+	}
+	d, err := s.codec.Decode(fdl.Name, fdl.Dispatchable)
+	if err != nil {
+		return DeadLetter{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return DeadLetter{
+		ID:            fdl.ID,
+		Dispatchable:  d,
+		Handler:       fdl.Handler,
+		Err:           fdl.Err,
+		Attempts:      fdl.Attempts,
+		FirstFailedAt: fdl.FirstFailedAt,
+		LastFailedAt:  fdl.LastFailedAt,
+	}, nil
+}
+
+// List implements the Store interface
+func (s FileStore) List(_ context.Context) ([]DeadLetter, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var dls []DeadLetter
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		dl, err := s.read(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		dls = append(dls, dl)
+	}
+	sortByAge(dls)
+	return dls, nil
+}
+
+// Delete implements the Store interface
+func (s FileStore) Delete(_ context.Context, id uuid.UUID) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
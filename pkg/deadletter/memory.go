@@ -0,0 +1,71 @@
+package deadletter
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-memory dead letters store
+type MemoryStore struct {
+	mux *sync.RWMutex
+	dls map[uuid.UUID]DeadLetter
+}
+
+// NewMemoryStore is a constructor
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{
+		mux: &sync.RWMutex{},
+		dls: make(map[uuid.UUID]DeadLetter),
+	}
+}
+
+// Save implements the Store interface
+func (s MemoryStore) Save(_ context.Context, dl DeadLetter) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.dls[dl.ID] = dl
+	return nil
+}
+
+// Get implements the Store interface
+func (s MemoryStore) Get(_ context.Context, id uuid.UUID) (DeadLetter, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	dl, ok := s.dls[id]
+	if !ok {
+		return DeadLetter{}, ErrNotFound
+	}
+	return dl, nil
+}
+
+// List implements the Store interface
+func (s MemoryStore) List(_ context.Context) ([]DeadLetter, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	dls := make([]DeadLetter, 0, len(s.dls))
+	for _, dl := range s.dls {
+		dls = append(dls, dl)
+	}
+	sortByAge(dls)
+	return dls, nil
+}
+
+// Delete implements the Store interface
+func (s MemoryStore) Delete(_ context.Context, id uuid.UUID) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, ok := s.dls[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.dls, id)
+	return nil
+}
+
+func sortByAge(dls []DeadLetter) {
+	sort.SliceStable(dls, func(i, j int) bool {
+		return dls[i].FirstFailedAt.Before(dls[j].FirstFailedAt)
+	})
+}
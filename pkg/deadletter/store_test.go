@@ -0,0 +1,102 @@
+package deadletter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/codec"
+	"github.com/theskyinflames/cqrs-eda/pkg/deadletter"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type addUserCmd struct {
+	UserName string
+}
+
+func (addUserCmd) Name() string { return "add_user" }
+
+func TestStores(t *testing.T) {
+	stores := []struct {
+		name     string
+		newStore func(t *testing.T) deadletter.Store
+	}{
+		{
+			name: "memory store",
+			newStore: func(_ *testing.T) deadletter.Store {
+				return deadletter.NewMemoryStore()
+			},
+		},
+		{
+			name: "file store",
+			newStore: func(t *testing.T) deadletter.Store {
+				registry := codec.NewRegistry(events.JSONEncoder{})
+				registry.Register(addUserCmd{})
+				s, err := deadletter.NewFileStore(t.TempDir(), registry)
+				require.NoError(t, err)
+				return s
+			},
+		},
+	}
+
+	for _, st := range stores {
+		t.Run(`Given a `+st.name+`, when dead letters are saved, then they can be listed, inspected and deleted`, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+				s   = st.newStore(t)
+				now = time.Now().UTC().Truncate(time.Millisecond)
+				dl1 = deadletter.DeadLetter{
+					ID:            uuid.New(),
+					Dispatchable:  addUserCmd{UserName: "Bond"},
+					Handler:       "h",
+					Err:           "an error",
+					Attempts:      2,
+					FirstFailedAt: now,
+					LastFailedAt:  now.Add(time.Second),
+				}
+				dl2 = deadletter.DeadLetter{
+					ID:            uuid.New(),
+					Dispatchable:  addUserCmd{UserName: "Moneypenny"},
+					Handler:       "h",
+					Err:           "another error",
+					Attempts:      1,
+					FirstFailedAt: now.Add(-time.Minute),
+					LastFailedAt:  now.Add(-time.Minute),
+				}
+			)
+
+			_, err := s.Get(ctx, dl1.ID)
+			require.ErrorIs(t, err, deadletter.ErrNotFound)
+
+			require.NoError(t, s.Save(ctx, dl1))
+			require.NoError(t, s.Save(ctx, dl2))
+
+			got, err := s.Get(ctx, dl1.ID)
+			require.NoError(t, err)
+			require.Equal(t, dl1.Dispatchable, got.Dispatchable)
+			require.Equal(t, dl1.Attempts, got.Attempts)
+			require.True(t, dl1.LastFailedAt.Equal(got.LastFailedAt))
+
+			dls, err := s.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, dls, 2)
+			require.Equal(t, dl2.ID, dls[0].ID)
+			require.Equal(t, dl1.ID, dls[1].ID)
+
+			dl1.Attempts = 3
+			require.NoError(t, s.Save(ctx, dl1))
+			got, err = s.Get(ctx, dl1.ID)
+			require.NoError(t, err)
+			require.Equal(t, 3, got.Attempts)
+
+			require.NoError(t, s.Delete(ctx, dl1.ID))
+			require.ErrorIs(t, s.Delete(ctx, dl1.ID), deadletter.ErrNotFound)
+			dls, err = s.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, dls, 1)
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package events
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrUnsupportedType is returned when the binary encoder can't encode or decode a type
+var ErrUnsupportedType = errors.New("unsupported type")
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// BinaryEncoder is an Encoder with a compact binary format. Unlike gob, it doesn't include any type information,
+// so the values must be decoded to the same type they were encoded from. Integers are encoded as varints,
+// the exported struct fields in their declaration order, and the types that implement both encoding.BinaryMarshaler
+// and encoding.BinaryUnmarshaler, like time.Time and uuid.UUID, with them. Interfaces, channels, functions
+// and complex numbers are not supported.
+type BinaryEncoder struct{}
+
+// Marshal implements the Encoder interface
+func (BinaryEncoder) Marshal(v interface{}) ([]byte, error) {
+	return appendValue(nil, reflect.ValueOf(v))
+}
+
+// Unmarshal implements the Encoder interface. v must be a not nil pointer.
+func (BinaryEncoder) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: %T, expected a not nil pointer", ErrUnsupportedType, v)
+	}
+	r := bytes.NewReader(data)
+	if err := readValue(r, rv.Elem()); err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%d trailing bytes", r.Len())
+	}
+	return nil
+}
+
+func isBinaryMarshaler(t reflect.Type) bool {
+	return t.Implements(binaryMarshalerType) && reflect.PtrTo(t).Implements(binaryUnmarshalerType)
+}
+
+func appendValue(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("%w: nil", ErrUnsupportedType)
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && isBinaryMarshaler(t) {
+		data, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(b, data), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(b, v.Uint()), nil
+	case reflect.Float32:
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), nil
+	case reflect.String:
+		return appendBytes(b, []byte(v.String())), nil
+	case reflect.Slice:
+		// The length is shifted by one, so 0 means nil
+		if v.IsNil() {
+			return append(b, 0), nil
+		}
+		b = binary.AppendUvarint(b, uint64(v.Len())+1)
+		if t.Elem().Kind() == reflect.Uint8 {
+			return append(b, v.Bytes()...), nil
+		}
+		return appendElems(b, v)
+	case reflect.Array:
+		return appendElems(b, v)
+	case reflect.Map:
+		if v.IsNil() {
+			return append(b, 0), nil
+		}
+		b = binary.AppendUvarint(b, uint64(v.Len())+1)
+		var err error
+		for it := v.MapRange(); it.Next(); {
+			if b, err = appendValue(b, it.Key()); err != nil {
+				return nil, err
+			}
+			if b, err = appendValue(b, it.Value()); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Struct:
+		var err error
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if b, err = appendValue(b, v.Field(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return append(b, 0), nil
+		}
+		return appendValue(append(b, 1), v.Elem())
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+}
+
+func appendBytes(b, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendElems(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	for i := 0; i < v.Len(); i++ {
+		if b, err = appendValue(b, v.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func readValue(r *bytes.Reader, v reflect.Value) error {
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && isBinaryMarshaler(t) {
+		data, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if c > 1 {
+			return fmt.Errorf("invalid bool %d", c)
+		}
+		v.SetBool(c == 1)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, t)
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("%d overflows %s", n, t)
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32:
+		var bits uint32
+		err := binary.Read(r, binary.LittleEndian, &bits)
+		v.SetFloat(float64(math.Float32frombits(bits)))
+		return err
+	case reflect.Float64:
+		var bits uint64
+		err := binary.Read(r, binary.LittleEndian, &bits)
+		v.SetFloat(math.Float64frombits(bits))
+		return err
+	case reflect.String:
+		data, err := readBytes(r)
+		v.SetString(string(data))
+		return err
+	case reflect.Slice:
+		n, err := readLen(r)
+		if err != nil || n < 0 {
+			return err
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			if n > r.Len() {
+				return fmt.Errorf("length %d exceeds the data", n)
+			}
+			data := make([]byte, n)
+			if _, err := r.Read(data); err != nil && n > 0 {
+				return err
+			}
+			v.SetBytes(data)
+			return nil
+		}
+		// The elements that take no bytes can't be checked against the data, but nor do they take memory
+		if t.Elem().Size() == 0 && isEmptyEncoded(t.Elem()) {
+			v.Set(reflect.MakeSlice(t, n, n))
+			return nil
+		}
+		// The length isn't trusted, so the slice grows as the elements are read
+		s := reflect.MakeSlice(t, 0, minInt(n, r.Len()))
+		for i := 0; i < n; i++ {
+			e := reflect.New(t.Elem()).Elem()
+			if err := readValue(r, e); err != nil {
+				return err
+			}
+			s = reflect.Append(s, e)
+		}
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		return readElems(r, v)
+	case reflect.Map:
+		n, err := readLen(r)
+		if err != nil || n < 0 {
+			return err
+		}
+		v.Set(reflect.MakeMapWithSize(t, minInt(n, r.Len())))
+		// If neither the keys nor the values take bytes, all the entries are the same one
+		if n > 1 && isEmptyEncoded(t.Key()) && isEmptyEncoded(t.Elem()) {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			key, value := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+			if err := readValue(r, key); err != nil {
+				return err
+			}
+			if err := readValue(r, value); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+		return nil
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := readValue(r, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Ptr:
+		c, err := r.ReadByte()
+		if err != nil || c == 0 {
+			return err
+		}
+		v.Set(reflect.New(t.Elem()))
+		return readValue(r, v.Elem())
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+}
+
+// readLen reads the length of a slice or a map, which is -1 when it's nil
+func readLen(r *bytes.Reader) (int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 && n-1 > math.MaxInt {
+		return 0, fmt.Errorf("length %d overflows int", n-1)
+	}
+	return int(n) - 1, nil
+}
+
+// isEmptyEncoded returns whether the values of the type are encoded with no bytes, like struct{}
+func isEmptyEncoded(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr && isBinaryMarshaler(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() == 0 || isEmptyEncoded(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() && !isEmptyEncoded(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("length %d exceeds the data", n)
+	}
+	data := make([]byte, n)
+	_, err = r.Read(data)
+	if n == 0 {
+		err = nil
+	}
+	return data, err
+}
+
+func readElems(r *bytes.Reader, v reflect.Value) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := readValue(r, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
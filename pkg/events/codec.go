@@ -0,0 +1,291 @@
+package events
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownEvent is returned when decoding an event whose name has not been registered
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Encoder turns values into bytes and back
+type Encoder interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONEncoder is an Encoder that uses encoding/json
+type JSONEncoder struct{}
+
+// Marshal implements the Encoder interface
+func (JSONEncoder) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements the Encoder interface
+func (JSONEncoder) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobEncoder is an Encoder that uses encoding/gob
+type GobEncoder struct{}
+
+// Marshal implements the Encoder interface
+func (GobEncoder) Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal implements the Encoder interface
+func (GobEncoder) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// RegistryOpt is an option for the registry constructor
+type RegistryOpt func(*Registry)
+
+// WithUpcasters makes the registry upcast the EventBasic events when they are decoded
+func WithUpcasters(u Upcasters) RegistryOpt {
+	return func(r *Registry) {
+		r.upcasters = &u
+	}
+}
+
+// Registry maps the event names to their Go types, to encode the events and decode them back.
+// It implements the Codec interfaces of the outbox and the event store.
+//
+// Events of any type are encoded with their exported fields. EventBasic events are encoded losslessly,
+// including their metadata, and their bodies are decoded to the type of the registered prototype body.
+type Registry struct {
+	mux       *sync.RWMutex
+	enc       Encoder
+	types     map[string]reflect.Type
+	bodies    map[string]reflect.Type
+	upcasters *Upcasters
+}
+
+// NewRegistry is a constructor
+func NewRegistry(enc Encoder, opts ...RegistryOpt) Registry {
+	r := Registry{
+		mux:    &sync.RWMutex{},
+		enc:    enc,
+		types:  make(map[string]reflect.Type),
+		bodies: make(map[string]reflect.Type),
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// Register sets the types the events with the name of each prototype are decoded to.
+// A prototype is a value of the event type, a pointer or not. For EventBasic prototypes,
+// the type of their body is registered too, like in NewEventBasic(uuid.Nil, "user_added", UserAdded{}).
+func (r Registry) Register(prototypes ...Event) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, p := range prototypes {
+		r.types[p.Name()] = reflect.TypeOf(p)
+		if eb, ok := basic(p); ok && eb.body != nil {
+			r.bodies[p.Name()] = reflect.TypeOf(eb.body)
+		}
+	}
+}
+
+func basic(e Event) (EventBasic, bool) {
+	switch eb := e.(type) {
+	case EventBasic:
+		return eb, true
+	case *EventBasic:
+		return *eb, eb != nil
+	}
+	return EventBasic{}, false
+}
+
+// Encode encodes the event
+func (r Registry) Encode(e Event) ([]byte, error) {
+	if _, ok := basic(e); !ok {
+		return r.enc.Marshal(e)
+	}
+	raw, err := r.EncodeRaw(e)
+	if err != nil {
+		return nil, err
+	}
+	return r.enc.Marshal(raw)
+}
+
+// EncodeRaw returns the raw event of an event, with its body encoded. For events other than EventBasic,
+// the body is the whole encoded event, and the ID and the metadata are taken from it if it has them.
+func (r Registry) EncodeRaw(e Event) (RawEvent, error) {
+	eb, ok := basic(e)
+	if !ok {
+		raw := RawEvent{AggregateID: e.AggregateID(), Name: e.Name()}
+		if ie, ok := e.(interface{ EventID() uuid.UUID }); ok {
+			raw.ID = ie.EventID()
+		}
+		if ee, ok := e.(Enveloped); ok {
+			raw.Metadata = ee.Metadata()
+		}
+		body, err := r.enc.Marshal(e)
+		if err != nil {
+			return RawEvent{}, fmt.Errorf("encoding event %s: %w", e.Name(), err)
+		}
+		raw.Body = body
+		return raw, nil
+	}
+
+	raw := RawEvent{
+		ID:          eb.ID,
+		AggregateID: eb.aggregateID,
+		Name:        eb.name,
+		Metadata:    eb.metadata,
+	}
+	if eb.body != nil {
+		body, err := r.enc.Marshal(eb.body)
+		if err != nil {
+			return RawEvent{}, fmt.Errorf("encoding body of event %s: %w", eb.name, err)
+		}
+		raw.Body = body
+	}
+	return raw, nil
+}
+
+// Decode decodes an event with the given name to its registered type.
+// If the event is split by the upcasters, an error is returned. Use DecodeAll instead.
+func (r Registry) Decode(name string, data []byte) (Event, error) {
+	evs, err := r.DecodeAll(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(evs) != 1 {
+		return nil, fmt.Errorf("event %s has been upcast to %d events", name, len(evs))
+	}
+	return evs[0], nil
+}
+
+// DecodeAll decodes an event with the given name to its registered type. If there are upcasters
+// for it, it's upcast to its current schema version first, so it can be decoded to several events.
+func (r Registry) DecodeAll(name string, data []byte) ([]Event, error) {
+	t, _, ok := r.lookup(name)
+	if !ok && !r.upcast(name) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+	}
+	if ok && !isBasic(t) && !r.upcast(name) {
+		e, err := r.decode(t, data)
+		if err != nil {
+			return nil, err
+		}
+		return []Event{e}, nil
+	}
+
+	var raw RawEvent
+	if err := r.enc.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return r.DecodeRaw(raw)
+}
+
+// DecodeRaw decodes a raw event to its registered type, upcasting it like DecodeAll does.
+// For events other than EventBasic, the body must be the whole encoded event, like EncodeRaw does.
+func (r Registry) DecodeRaw(raw RawEvent) ([]Event, error) {
+	t, _, ok := r.lookup(raw.Name)
+	if !ok && !r.upcast(raw.Name) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, raw.Name)
+	}
+	if ok && !isBasic(t) && !r.upcast(raw.Name) {
+		e, err := r.decode(t, raw.Body)
+		if err != nil {
+			return nil, err
+		}
+		return []Event{e}, nil
+	}
+
+	raws := []RawEvent{raw}
+	if r.upcasters != nil {
+		var err error
+		if raws, err = r.upcasters.Upcast(raw); err != nil {
+			return nil, err
+		}
+	}
+	evs := make([]Event, 0, len(raws))
+	for _, raw := range raws {
+		t, bodyType, ok := r.lookup(raw.Name)
+		if !ok || !isBasic(t) {
+			return nil, fmt.Errorf("%w: %s, not registered as EventBasic", ErrUnknownEvent, raw.Name)
+		}
+		eb, err := r.decodeBasic(raw, bodyType)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind() == reflect.Ptr {
+			evs = append(evs, &eb)
+			continue
+		}
+		evs = append(evs, eb)
+	}
+	return evs, nil
+}
+
+func (r Registry) upcast(name string) bool {
+	return r.upcasters != nil && r.upcasters.has(name)
+}
+
+func (r Registry) lookup(name string) (t, bodyType reflect.Type, ok bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	t, ok = r.types[name]
+	return t, r.bodies[name], ok
+}
+
+func isBasic(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == reflect.TypeOf(EventBasic{})
+}
+
+func (r Registry) decode(t reflect.Type, data []byte) (Event, error) {
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if err := r.enc.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	if isPtr {
+		return v.Interface().(Event), nil
+	}
+	return v.Elem().Interface().(Event), nil
+}
+
+func (r Registry) decodeBasic(env RawEvent, bodyType reflect.Type) (EventBasic, error) {
+	eb := EventBasic{
+		ID:          env.ID,
+		aggregateID: env.AggregateID,
+		name:        env.Name,
+		metadata:    env.Metadata,
+	}
+	if len(env.Body) == 0 || bytes.Equal(env.Body, []byte("null")) {
+		return eb, nil
+	}
+	if bodyType == nil {
+		return EventBasic{}, fmt.Errorf("decoding body of event %s: no body type registered", env.Name)
+	}
+	body := reflect.New(bodyType)
+	if err := r.enc.Unmarshal(env.Body, body.Interface()); err != nil {
+		return EventBasic{}, fmt.Errorf("decoding body of event %s: %w", env.Name, err)
+	}
+	eb.body = body.Elem().Interface()
+	return eb, nil
+}
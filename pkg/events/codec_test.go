@@ -0,0 +1,155 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type userAddedBody struct {
+	UserName string
+	Roles    []string
+	Age      int
+	Score    float64
+	Active   bool
+	Manager  *uuid.UUID
+	Tags     map[string]int
+}
+
+type userRemoved struct {
+	ID     uuid.UUID
+	Reason string
+	At     time.Time
+}
+
+func (userRemoved) Name() string { return "user_removed" }
+
+func (e userRemoved) AggregateID() uuid.UUID { return e.ID }
+
+func TestRegistry(t *testing.T) {
+	var (
+		manager = uuid.New()
+		added   = events.NewEventBasic(uuid.New(), "user_added", userAddedBody{
+			UserName: "Bond",
+			Roles:    []string{"agent"},
+			Age:      -7,
+			Score:    0.07,
+			Active:   true,
+			Manager:  &manager,
+			Tags:     map[string]int{"mi6": 6},
+		},
+			events.WithOccurredAt(time.Now().UTC().Truncate(time.Millisecond)),
+			events.WithAggregateVersion(3),
+			events.WithCorrelation(uuid.New(), uuid.New()),
+			events.WithHeader("tenant", "acme"),
+		)
+		removed = userRemoved{ID: uuid.New(), Reason: "retired", At: time.Now().UTC().Truncate(time.Millisecond)}
+	)
+
+	encoders := map[string]events.Encoder{
+		"JSON":   events.JSONEncoder{},
+		"gob":    events.GobEncoder{},
+		"binary": events.BinaryEncoder{},
+	}
+	for name, enc := range encoders {
+		r := events.NewRegistry(enc)
+		r.Register(events.NewEventBasic(uuid.Nil, "user_added", userAddedBody{}), &userRemoved{})
+
+		t.Run(`Given a registry with the `+name+` encoder, when an EventBasic is round-tripped, then it's kept as is`, func(t *testing.T) {
+			data, err := r.Encode(added)
+			require.NoError(t, err)
+			got, err := r.Decode(added.Name(), data)
+			require.NoError(t, err)
+			require.Equal(t, added, got)
+		})
+
+		t.Run(`Given a registry with the `+name+` encoder, when an EventBasic without body is round-tripped, then it's kept as is`, func(t *testing.T) {
+			e := events.NewEventBasic(uuid.New(), "user_added", nil, events.WithOccurredAt(time.Time{}))
+			data, err := r.Encode(e)
+			require.NoError(t, err)
+			got, err := r.Decode(e.Name(), data)
+			require.NoError(t, err)
+			require.Equal(t, e, got)
+		})
+
+		t.Run(`Given a registry with the `+name+` encoder, when a custom event is round-tripped, then it's decoded to its registered type`, func(t *testing.T) {
+			data, err := r.Encode(removed)
+			require.NoError(t, err)
+			got, err := r.Decode(removed.Name(), data)
+			require.NoError(t, err)
+			require.Equal(t, &removed, got)
+		})
+
+		t.Run(`Given a registry with the `+name+` encoder, when a not registered event is decoded, then an error is returned`, func(t *testing.T) {
+			_, err := r.Decode("unknown", nil)
+			require.ErrorIs(t, err, events.ErrUnknownEvent)
+		})
+	}
+}
+
+func TestBinaryEncoder(t *testing.T) {
+	t.Run(`Given the binary encoder, when a value is encoded, then it's more compact than with JSON`, func(t *testing.T) {
+		v := userAddedBody{UserName: "Bond", Roles: []string{"agent"}, Age: 7}
+		bin, err := events.BinaryEncoder{}.Marshal(v)
+		require.NoError(t, err)
+		js, err := events.JSONEncoder{}.Marshal(v)
+		require.NoError(t, err)
+		require.Less(t, len(bin), len(js))
+
+		var got userAddedBody
+		require.NoError(t, events.BinaryEncoder{}.Unmarshal(bin, &got))
+		require.Equal(t, v, got)
+	})
+
+	t.Run(`Given the binary encoder, when a value with an interface is encoded, then an error is returned`, func(t *testing.T) {
+		_, err := events.BinaryEncoder{}.Marshal(struct{ V interface{} }{V: 1})
+		require.ErrorIs(t, err, events.ErrUnsupportedType)
+	})
+
+	t.Run(`Given the binary encoder, when truncated data is decoded, then an error is returned`, func(t *testing.T) {
+		bin, err := events.BinaryEncoder{}.Marshal("a long enough string")
+		require.NoError(t, err)
+		var got string
+		require.Error(t, events.BinaryEncoder{}.Unmarshal(bin[:5], &got))
+	})
+
+	t.Run(`Given the binary encoder, when values without encoded bytes are round-tripped, then they're kept as is`, func(t *testing.T) {
+		v := struct {
+			Empty []struct{}
+			Set   map[struct{}]struct{}
+		}{Empty: make([]struct{}, 5), Set: map[struct{}]struct{}{{}: {}}}
+		bin, err := events.BinaryEncoder{}.Marshal(v)
+		require.NoError(t, err)
+
+		got := v
+		got.Empty, got.Set = nil, nil
+		require.NoError(t, events.BinaryEncoder{}.Unmarshal(bin, &got))
+		require.Equal(t, v, got)
+	})
+
+	t.Run(`Given the binary encoder, when a slice with a length exceeding the data is decoded, then an error is returned`, func(t *testing.T) {
+		var got []string
+		require.Error(t, events.BinaryEncoder{}.Unmarshal([]byte{0xff, 0xff, 0xff, 0xff, 0x7f, 1, 'a'}, &got))
+	})
+
+	t.Run(`Given the binary encoder, when an integer overflowing its type is decoded, then an error is returned`, func(t *testing.T) {
+		bin, err := events.BinaryEncoder{}.Marshal(300)
+		require.NoError(t, err)
+		var i8 int8
+		require.Error(t, events.BinaryEncoder{}.Unmarshal(bin, &i8))
+
+		bin, err = events.BinaryEncoder{}.Marshal(uint(300))
+		require.NoError(t, err)
+		var u8 uint8
+		require.Error(t, events.BinaryEncoder{}.Unmarshal(bin, &u8))
+	})
+
+	t.Run(`Given the binary encoder, when a bool other than 0 or 1 is decoded, then an error is returned`, func(t *testing.T) {
+		var got bool
+		require.Error(t, events.BinaryEncoder{}.Unmarshal([]byte{2}, &got))
+	})
+}
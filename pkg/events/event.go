@@ -1,6 +1,10 @@
 package events
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // EventBasic is a domain event
 type EventBasic struct {
@@ -8,16 +12,30 @@ type EventBasic struct {
 	aggregateID uuid.UUID
 	name        string
 	body        interface{}
+	metadata    Metadata
 }
 
 // NewEventBasic is a constructor
-func NewEventBasic(aggregateID uuid.UUID, name string, body interface{}) EventBasic {
-	return EventBasic{
+func NewEventBasic(aggregateID uuid.UUID, name string, body interface{}, opts ...EventOpt) EventBasic {
+	e := EventBasic{
 		ID:          uuid.New(),
 		aggregateID: aggregateID,
 		name:        name,
 		body:        body,
+		metadata: Metadata{
+			OccurredAt:    time.Now().UTC(),
+			SchemaVersion: 1,
+		},
+	}
+	for _, opt := range opts {
+		opt(&e)
 	}
+	return e
+}
+
+// EventID is a getter
+func (e EventBasic) EventID() uuid.UUID {
+	return e.ID
 }
 
 // Name is a getter
@@ -34,3 +52,13 @@ func (e EventBasic) AggregateID() uuid.UUID {
 func (e EventBasic) Body() interface{} {
 	return e.body
 }
+
+// Metadata is a getter
+func (e EventBasic) Metadata() Metadata {
+	return e.metadata.clone()
+}
+
+// SetMetadata is a setter
+func (e *EventBasic) SetMetadata(m Metadata) {
+	e.metadata = m.clone()
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Metadata describes an event occurrence
+type Metadata struct {
+	// OccurredAt is when the event was raised
+	OccurredAt time.Time `json:"occurred_at"`
+	// AggregateVersion is the version of the aggregate after the event
+	AggregateVersion int `json:"aggregate_version,omitempty"`
+	// SchemaVersion is the version of the event body structure
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// CorrelationID identifies the whole flow of commands and events the event belongs to
+	CorrelationID uuid.UUID `json:"correlation_id"`
+	// CausationID identifies the command or event that caused the event
+	CausationID uuid.UUID `json:"causation_id"`
+	// Headers are arbitrary key-values
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func (m Metadata) clone() Metadata {
+	if m.Headers != nil {
+		headers := make(map[string]string, len(m.Headers))
+		for k, v := range m.Headers {
+			headers[k] = v
+		}
+		m.Headers = headers
+	}
+	return m
+}
+
+// Enveloped is an event with metadata, like EventBasic
+type Enveloped interface {
+	Event
+	Metadata() Metadata
+}
+
+// EventOpt is an option for the EventBasic constructor
+type EventOpt func(*EventBasic)
+
+// WithOccurredAt sets when the event was raised. By default, it's when it's built.
+func WithOccurredAt(t time.Time) EventOpt {
+	return func(e *EventBasic) {
+		e.metadata.OccurredAt = t
+	}
+}
+
+// WithAggregateVersion sets the version of the aggregate after the event
+func WithAggregateVersion(v int) EventOpt {
+	return func(e *EventBasic) {
+		e.metadata.AggregateVersion = v
+	}
+}
+
+// WithSchemaVersion sets the version of the event body structure. By default, it's 1.
+func WithSchemaVersion(v int) EventOpt {
+	return func(e *EventBasic) {
+		e.metadata.SchemaVersion = v
+	}
+}
+
+// WithCorrelation sets the correlation and causation IDs of the event
+func WithCorrelation(correlationID, causationID uuid.UUID) EventOpt {
+	return func(e *EventBasic) {
+		e.metadata.CorrelationID = correlationID
+		e.metadata.CausationID = causationID
+	}
+}
+
+// WithHeader sets a header of the event
+func WithHeader(key, value string) EventOpt {
+	return func(e *EventBasic) {
+		if e.metadata.Headers == nil {
+			e.metadata.Headers = make(map[string]string)
+		}
+		e.metadata.Headers[key] = value
+	}
+}
+
+type correlationCtxKey struct{}
+
+type correlation struct {
+	correlationID, causationID uuid.UUID
+}
+
+// ContextWithCorrelation returns a copy of ctx that carries the correlation and causation IDs
+// to be set to the events raised while handling it
+func ContextWithCorrelation(ctx context.Context, correlationID, causationID uuid.UUID) context.Context {
+	return context.WithValue(ctx, correlationCtxKey{}, correlation{correlationID: correlationID, causationID: causationID})
+}
+
+// CorrelationFromContext returns the correlation and causation IDs carried by ctx, if any
+func CorrelationFromContext(ctx context.Context) (correlationID, causationID uuid.UUID, ok bool) {
+	c, ok := ctx.Value(correlationCtxKey{}).(correlation)
+	return c.correlationID, c.causationID, ok
+}
+
+// ContextFromEvent returns a copy of ctx to handle the event, so the events raised while handling it
+// share its correlation ID, and are caused by it. If the event doesn't have a correlation ID, its ID is used.
+func ContextFromEvent(ctx context.Context, e Event) context.Context {
+	var id uuid.UUID
+	if ie, ok := e.(interface{ EventID() uuid.UUID }); ok {
+		id = ie.EventID()
+	}
+	correlationID := id
+	if ee, ok := e.(Enveloped); ok && ee.Metadata().CorrelationID != uuid.Nil {
+		correlationID = ee.Metadata().CorrelationID
+	}
+	return ContextWithCorrelation(ctx, correlationID, id)
+}
+
+// UpdateMetadata returns the event with its metadata changed by f, and whether it could be changed.
+// EventBasic values and the events with a SetMetadata method, like *EventBasic or pointers to structs
+// that embed it, are updated. Struct values that embed EventBasic, like the domain events usually are,
+// can't be changed in place, so a copy of them is updated and returned. The other events are returned as they are.
+func UpdateMetadata(e Event, f func(m Metadata) Metadata) (Event, bool) {
+	switch ev := e.(type) {
+	case EventBasic:
+		ev.SetMetadata(f(ev.Metadata()))
+		return ev, true
+	case interface {
+		Enveloped
+		SetMetadata(m Metadata)
+	}:
+		ev.SetMetadata(f(ev.Metadata()))
+		return ev, true
+	}
+
+	v := reflect.ValueOf(e)
+	if v.Kind() != reflect.Struct {
+		return e, false
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.Anonymous || field.Type != reflect.TypeOf(EventBasic{}) {
+			continue
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		eb := cp.Field(i).Addr().Interface().(*EventBasic)
+		eb.SetMetadata(f(eb.Metadata()))
+		return cp.Interface().(Event), true
+	}
+	return e, false
+}
+
+// Propagate sets the correlation and causation IDs carried by ctx to the event, unless it already has them.
+// It returns the updated event, see UpdateMetadata. The events without metadata are returned as they are,
+// so embed EventBasic into the domain events to have them correlated.
+func Propagate(ctx context.Context, e Event) Event {
+	correlationID, causationID, ok := CorrelationFromContext(ctx)
+	if !ok {
+		return e
+	}
+	e, _ = UpdateMetadata(e, func(m Metadata) Metadata {
+		if m.CorrelationID == uuid.Nil {
+			m.CorrelationID = correlationID
+		}
+		if m.CausationID == uuid.Nil {
+			m.CausationID = causationID
+		}
+		return m
+	})
+	return e
+}
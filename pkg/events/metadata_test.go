@@ -0,0 +1,98 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type userAdded struct {
+	events.EventBasic
+}
+
+func TestEventBasicMetadata(t *testing.T) {
+	t.Run(`Given an event built with options, when its metadata is requested, then it has the options values`, func(t *testing.T) {
+		var (
+			occurredAt    = time.Now().Add(-time.Minute)
+			correlationID = uuid.New()
+			causationID   = uuid.New()
+		)
+		e := events.NewEventBasic(uuid.New(), "user_added", nil,
+			events.WithOccurredAt(occurredAt),
+			events.WithAggregateVersion(3),
+			events.WithSchemaVersion(2),
+			events.WithCorrelation(correlationID, causationID),
+			events.WithHeader("tenant", "acme"),
+		)
+		require.Equal(t, events.Metadata{
+			OccurredAt:       occurredAt,
+			AggregateVersion: 3,
+			SchemaVersion:    2,
+			CorrelationID:    correlationID,
+			CausationID:      causationID,
+			Headers:          map[string]string{"tenant": "acme"},
+		}, e.Metadata())
+
+		e.Metadata().Headers["tenant"] = "changed"
+		require.Equal(t, "acme", e.Metadata().Headers["tenant"])
+	})
+
+	t.Run(`Given an event built without options, when its metadata is requested, then it has the default values`, func(t *testing.T) {
+		e := events.NewEventBasic(uuid.New(), "user_added", nil)
+		require.WithinDuration(t, time.Now(), e.Metadata().OccurredAt, time.Second)
+		require.Equal(t, 1, e.Metadata().SchemaVersion)
+	})
+}
+
+func TestPropagate(t *testing.T) {
+	var (
+		correlationID = uuid.New()
+		causationID   = uuid.New()
+		ctx           = events.ContextWithCorrelation(context.Background(), correlationID, causationID)
+	)
+
+	t.Run(`Given a context with correlation, when it's propagated to an event value, then an updated copy is returned`, func(t *testing.T) {
+		e := events.NewEventBasic(uuid.New(), "user_added", nil)
+		got := events.Propagate(ctx, e).(events.EventBasic)
+		require.Equal(t, correlationID, got.Metadata().CorrelationID)
+		require.Equal(t, causationID, got.Metadata().CausationID)
+		require.Equal(t, uuid.Nil, e.Metadata().CorrelationID)
+	})
+
+	t.Run(`Given a context with correlation, when it's propagated to a pointer to an event that embeds EventBasic, then it's updated`, func(t *testing.T) {
+		e := &userAdded{EventBasic: events.NewEventBasic(uuid.New(), "user_added", nil)}
+		require.Equal(t, e, events.Propagate(ctx, e))
+		require.Equal(t, correlationID, e.Metadata().CorrelationID)
+		require.Equal(t, causationID, e.Metadata().CausationID)
+	})
+
+	t.Run(`Given a context with correlation, when it's propagated to an event value that embeds EventBasic, then an updated copy is returned`, func(t *testing.T) {
+		e := userAdded{EventBasic: events.NewEventBasic(uuid.New(), "user_added", nil)}
+		got, ok := events.Propagate(ctx, e).(userAdded)
+		require.True(t, ok)
+		require.Equal(t, correlationID, got.Metadata().CorrelationID)
+		require.Equal(t, causationID, got.Metadata().CausationID)
+		require.Equal(t, e.EventID(), got.EventID())
+		require.Equal(t, uuid.Nil, e.Metadata().CorrelationID)
+	})
+
+	t.Run(`Given a context with correlation, when it's propagated to an event with correlation, then it's kept`, func(t *testing.T) {
+		own := uuid.New()
+		e := events.NewEventBasic(uuid.New(), "user_added", nil, events.WithCorrelation(own, own))
+		got := events.Propagate(ctx, e).(events.EventBasic)
+		require.Equal(t, own, got.Metadata().CorrelationID)
+		require.Equal(t, own, got.Metadata().CausationID)
+	})
+
+	t.Run(`Given an event, when a context is built from it, then the events raised handling it are caused by it`, func(t *testing.T) {
+		cause := events.NewEventBasic(uuid.New(), "user_added", nil, events.WithCorrelation(correlationID, uuid.New()))
+		got := events.Propagate(events.ContextFromEvent(context.Background(), cause), events.NewEventBasic(uuid.New(), "email_sent", nil))
+		require.Equal(t, correlationID, got.(events.Enveloped).Metadata().CorrelationID)
+		require.Equal(t, cause.EventID(), got.(events.Enveloped).Metadata().CausationID)
+	})
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrUpcastLoop is returned when a chain of upcasters goes back to an event name and schema version it has already upcast
+var ErrUpcastLoop = errors.New("upcast loop")
+
+// RawEvent is an EventBasic before its body is decoded. It's the wire representation of EventBasic.
+type RawEvent struct {
+	ID          uuid.UUID `json:"id"`
+	AggregateID uuid.UUID `json:"aggregate_id"`
+	Name        string    `json:"name"`
+	Metadata    Metadata  `json:"metadata"`
+	// Body is encoded with the same Encoder as the event
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Upcaster transforms an event from an older schema version. It can change its name, its body,
+// or split it into several events. The returned events must have another name or schema version,
+// usually the next one.
+type Upcaster func(e RawEvent) ([]RawEvent, error)
+
+type upcastKey struct {
+	name    string
+	version int
+}
+
+// Upcasters is a chain of upcasters, registered by event name and schema version. An event is upcast
+// until there is no upcaster for its name and version, so it reaches the current schema version.
+type Upcasters struct {
+	mux       *sync.RWMutex
+	upcasters map[upcastKey]Upcaster
+	names     map[string]bool
+}
+
+// NewUpcasters is a constructor
+func NewUpcasters() Upcasters {
+	return Upcasters{
+		mux:       &sync.RWMutex{},
+		upcasters: make(map[upcastKey]Upcaster),
+		names:     make(map[string]bool),
+	}
+}
+
+// Register sets the upcaster for the events with the given name and schema version
+func (u Upcasters) Register(name string, schemaVersion int, up Upcaster) {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	u.upcasters[upcastKey{name: name, version: schemaVersion}] = up
+	u.names[name] = true
+}
+
+func (u Upcasters) has(name string) bool {
+	u.mux.RLock()
+	defer u.mux.RUnlock()
+	return u.names[name]
+}
+
+func (u Upcasters) get(name string, version int) (Upcaster, bool) {
+	u.mux.RLock()
+	defer u.mux.RUnlock()
+	up, ok := u.upcasters[upcastKey{name: name, version: version}]
+	return up, ok
+}
+
+// Upcast applies the chain to the event, returning it in its current schema version.
+// It fails with ErrUpcastLoop if the chain goes back to a name and schema version it has already upcast.
+func (u Upcasters) Upcast(e RawEvent) ([]RawEvent, error) {
+	return u.upcast(e, make(map[upcastKey]bool))
+}
+
+// upcast applies the chain to the event, where visited has the names and versions upcast to get to it
+func (u Upcasters) upcast(e RawEvent, visited map[upcastKey]bool) ([]RawEvent, error) {
+	k := upcastKey{name: e.Name, version: e.Metadata.SchemaVersion}
+	up, ok := u.get(k.name, k.version)
+	if !ok {
+		return []RawEvent{e}, nil
+	}
+	if visited[k] {
+		return nil, fmt.Errorf("%w: event %s, version %d", ErrUpcastLoop, e.Name, e.Metadata.SchemaVersion)
+	}
+	upcast, err := up(e)
+	if err != nil {
+		return nil, fmt.Errorf("upcasting event %s from version %d: %w", e.Name, e.Metadata.SchemaVersion, err)
+	}
+	visited[k] = true
+	defer delete(visited, k)
+
+	var evs []RawEvent
+	for _, ue := range upcast {
+		next, err := u.upcast(ue, visited)
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, next...)
+	}
+	return evs, nil
+}
+
+// UpcastJSONBody returns an upcaster that changes the JSON body of an event with f, like renaming its fields,
+// and bumps its schema version
+func UpcastJSONBody(f func(body map[string]interface{}) error) Upcaster {
+	return func(e RawEvent) ([]RawEvent, error) {
+		body := make(map[string]interface{})
+		if len(e.Body) > 0 {
+			if err := json.Unmarshal(e.Body, &body); err != nil {
+				return nil, err
+			}
+		}
+		if err := f(body); err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		e.Body = b
+		e.Metadata.SchemaVersion++
+		return []RawEvent{e}, nil
+	}
+}
+
+// Rename returns an upcaster that changes the name of an event, keeping its schema version
+func Rename(name string) Upcaster {
+	return func(e RawEvent) ([]RawEvent, error) {
+		e.Name = name
+		return []RawEvent{e}, nil
+	}
+}
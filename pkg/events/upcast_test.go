@@ -0,0 +1,114 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type userRegisteredBody struct {
+	FullName string
+}
+
+type emailSetBody struct {
+	Email string
+}
+
+func TestUpcasters(t *testing.T) {
+	var (
+		id  = uuid.New()
+		enc = events.JSONEncoder{}
+	)
+	old := events.NewEventBasic(id, "user_created", map[string]string{"name": "James Bond", "email": "007@mi6.uk"})
+
+	upcasters := events.NewUpcasters()
+	// v1: user_created is renamed to user_registered
+	upcasters.Register("user_created", 1, events.Rename("user_registered"))
+	// v1 -> v2: name field is renamed to full_name
+	upcasters.Register("user_registered", 1, events.UpcastJSONBody(func(body map[string]interface{}) error {
+		body["FullName"] = body["name"]
+		delete(body, "name")
+		return nil
+	}))
+	// v2 -> v3: the email is split into its own event
+	upcasters.Register("user_registered", 2, func(e events.RawEvent) ([]events.RawEvent, error) {
+		registered, emailSet := e, e
+		registered.Metadata.SchemaVersion = 3
+		emailSet.Name = "email_set"
+		emailSet.Metadata.SchemaVersion = 1
+		return []events.RawEvent{registered, emailSet}, nil
+	})
+
+	r := events.NewRegistry(enc, events.WithUpcasters(upcasters))
+	r.Register(
+		events.NewEventBasic(uuid.Nil, "user_registered", userRegisteredBody{}),
+		events.NewEventBasic(uuid.Nil, "email_set", emailSetBody{}),
+	)
+
+	t.Run(`Given a chain of upcasters, when an old event is decoded, then it's upcast to the current schema`, func(t *testing.T) {
+		data, err := r.Encode(old)
+		require.NoError(t, err)
+
+		evs, err := r.DecodeAll("user_created", data)
+		require.NoError(t, err)
+		require.Len(t, evs, 2)
+
+		registered := evs[0].(events.EventBasic)
+		require.Equal(t, "user_registered", registered.Name())
+		require.Equal(t, id, registered.AggregateID())
+		require.Equal(t, 3, registered.Metadata().SchemaVersion)
+		require.Equal(t, userRegisteredBody{FullName: "James Bond"}, registered.Body())
+
+		emailSet := evs[1].(events.EventBasic)
+		require.Equal(t, "email_set", emailSet.Name())
+		require.Equal(t, emailSetBody{Email: "007@mi6.uk"}, emailSet.Body())
+
+		_, err = r.Decode("user_created", data)
+		require.Error(t, err)
+	})
+
+	t.Run(`Given a chain of upcasters, when a current event is decoded, then it's kept as is`, func(t *testing.T) {
+		current := events.NewEventBasic(id, "user_registered", userRegisteredBody{FullName: "Bond"}, events.WithSchemaVersion(3))
+		data, err := r.Encode(current)
+		require.NoError(t, err)
+
+		got, err := r.Decode("user_registered", data)
+		require.NoError(t, err)
+		require.Equal(t, current.Body(), got.(events.EventBasic).Body())
+	})
+
+	t.Run(`Given an upcaster that doesn't change the event version nor name, when it's applied, then an error is returned`, func(t *testing.T) {
+		u := events.NewUpcasters()
+		u.Register("user_created", 1, func(e events.RawEvent) ([]events.RawEvent, error) {
+			return []events.RawEvent{e}, nil
+		})
+		_, err := u.Upcast(events.RawEvent{Name: "user_created", Metadata: events.Metadata{SchemaVersion: 1}})
+		require.ErrorIs(t, err, events.ErrUpcastLoop)
+	})
+
+	t.Run(`Given upcasters that rename two events into each other, when they're applied, then an error is returned`, func(t *testing.T) {
+		u := events.NewUpcasters()
+		u.Register("a", 1, events.Rename("b"))
+		u.Register("b", 1, events.Rename("a"))
+		_, err := u.Upcast(events.RawEvent{Name: "a", Metadata: events.Metadata{SchemaVersion: 1}})
+		require.ErrorIs(t, err, events.ErrUpcastLoop)
+	})
+
+	t.Run(`Given an upcaster that splits an event into two that share their next upcaster, when it's applied, then both are upcast`, func(t *testing.T) {
+		u := events.NewUpcasters()
+		u.Register("a", 1, func(e events.RawEvent) ([]events.RawEvent, error) {
+			b := e
+			b.Name = "b"
+			return []events.RawEvent{b, b}, nil
+		})
+		u.Register("b", 1, events.Rename("c"))
+		evs, err := u.Upcast(events.RawEvent{Name: "a", Metadata: events.Metadata{SchemaVersion: 1}})
+		require.NoError(t, err)
+		require.Len(t, evs, 2)
+		require.Equal(t, "c", evs[0].Name)
+		require.Equal(t, "c", evs[1].Name)
+	})
+}
@@ -0,0 +1,69 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// ErrConcurrencyConflict is returned when the expected version of an aggregate doesn't match its current one
+var ErrConcurrencyConflict = errors.New("concurrency conflict")
+
+// ConcurrencyConflictError is the ErrConcurrencyConflict returned by the event stores, with its details.
+// It can be caught with errors.Is(err, ErrConcurrencyConflict), or with errors.As to get the versions.
+type ConcurrencyConflictError struct {
+	AggregateID uuid.UUID
+	Expected    int
+	Actual      int
+}
+
+// Error implements the error interface
+func (e ConcurrencyConflictError) Error() string {
+	return fmt.Sprintf("%s: aggregate %s: expected version %d, actual version %d", ErrConcurrencyConflict, e.AggregateID, e.Expected, e.Actual)
+}
+
+// Is makes errors.Is match ErrConcurrencyConflict
+func (e ConcurrencyConflictError) Is(target error) bool {
+	return target == ErrConcurrencyConflict
+}
+
+// Codec encodes and decodes events to be persisted. If it has a DecodeAll(name, data) ([]events.Event, error) method,
+// like events.Registry with upcasters, the SQL store uses it, so the stored events can be upcast to several ones.
+type Codec interface {
+	Encode(e events.Event) ([]byte, error)
+	Decode(name string, data []byte) (events.Event, error)
+}
+
+// EventStore is an append-only store of the events of each aggregate. The version of an aggregate is
+// the number of events it has, so the first event of an aggregate is the version 1.
+type EventStore interface {
+	// Append adds the events to the aggregate stream, as long as its current version is expectedVersion.
+	// Otherwise, a ConcurrencyConflictError is returned and nothing is appended.
+	Append(ctx context.Context, aggregateID uuid.UUID, expectedVersion int, evs ...events.Event) error
+	// Load returns the events of the aggregate after the fromVersion one, in order.
+	// Use a fromVersion of 0 to load all of them.
+	Load(ctx context.Context, aggregateID uuid.UUID, fromVersion int) ([]events.Event, error)
+}
+
+// Record is an event stored in the event store, with its position in the stream of all the events.
+// When a stored event is upcast to several ones, their records share its position and version.
+type Record struct {
+	// Position is the global position of the event, increasing in the order the events were appended
+	Position    int64
+	AggregateID uuid.UUID
+	Version     int
+	Event       events.Event
+}
+
+// GlobalReader is an event store that can read the events of all the aggregates, in the order they were appended
+type GlobalReader interface {
+	// ReadAll returns up to limit records with a position greater than from, in order.
+	// Use a from of 0 to read from the beginning, and a limit of 0 to read all the records.
+	ReadAll(ctx context.Context, from int64, limit int) ([]Record, error)
+	// Head returns the position of the last appended event, or 0 if there are none
+	Head(ctx context.Context) (int64, error)
+}
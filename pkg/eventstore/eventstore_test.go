@@ -0,0 +1,62 @@
+package eventstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalReader(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		id          = uuid.New()
+		ev1         = userAdded{ID: id, UserName: "Bond"}
+		ev2         = userAdded{ID: id, UserName: "James Bond"}
+		payload1, _ = json.Marshal(ev1)
+		payload2, _ = json.Marshal(ev2)
+		registry    = events.NewRegistry(events.JSONEncoder{})
+	)
+	registry.Register(userAdded{})
+
+	// Each reader has the two events of the aggregate
+	readers := map[string]func(t *testing.T) eventstore.GlobalReader{
+		"memory store": func(t *testing.T) eventstore.GlobalReader {
+			s := eventstore.NewMemoryStore()
+			require.NoError(t, s.Append(ctx, id, 0, ev1, ev2))
+			return s
+		},
+		"SQL store": func(t *testing.T) eventstore.GlobalReader {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				require.NoError(t, mock.ExpectationsWereMet())
+				db.Close()
+			})
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT position, aggregate_id, version, name, payload FROM events WHERE position > ? ORDER BY position`) + "$").
+				WithArgs(0).
+				WillReturnRows(sqlmock.NewRows([]string{"position", "aggregate_id", "version", "name", "payload"}).
+					AddRow(1, id.String(), 1, ev1.Name(), payload1).
+					AddRow(2, id.String(), 2, ev2.Name(), payload2))
+			return eventstore.NewSQLStore(db, registry)
+		},
+	}
+
+	for name, newReader := range readers {
+		t.Run(`Given a `+name+`, when all the events are read with a limit of 0, then all of them are returned`, func(t *testing.T) {
+			records, err := newReader(t).ReadAll(ctx, 0, 0)
+			require.NoError(t, err)
+			require.Equal(t, []eventstore.Record{
+				{Position: 1, AggregateID: id, Version: 1, Event: ev1},
+				{Position: 2, AggregateID: id, Version: 2, Event: ev2},
+			}, records)
+		})
+	}
+}
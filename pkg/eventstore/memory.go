@@ -0,0 +1,103 @@
+package eventstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-memory event store. It implements GlobalReader too.
+type MemoryStore struct {
+	mux     *sync.RWMutex
+	streams map[uuid.UUID][]events.Event
+	log     *globalLog
+}
+
+type globalLog struct {
+	records []Record
+	// changed is closed, and replaced, when events are appended
+	changed chan struct{}
+}
+
+// NewMemoryStore is a constructor
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{
+		mux:     &sync.RWMutex{},
+		streams: make(map[uuid.UUID][]events.Event),
+		log:     &globalLog{changed: make(chan struct{})},
+	}
+}
+
+// Append implements the EventStore interface
+func (s MemoryStore) Append(_ context.Context, aggregateID uuid.UUID, expectedVersion int, evs ...events.Event) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	stream := s.streams[aggregateID]
+	if len(stream) != expectedVersion {
+		return ConcurrencyConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: len(stream)}
+	}
+	s.streams[aggregateID] = append(stream[:len(stream):len(stream)], evs...)
+	for i, e := range evs {
+		s.log.records = append(s.log.records, Record{
+			Position:    int64(len(s.log.records) + 1),
+			AggregateID: aggregateID,
+			Version:     expectedVersion + i + 1,
+			Event:       e,
+		})
+	}
+	if len(evs) > 0 {
+		close(s.log.changed)
+		s.log.changed = make(chan struct{})
+	}
+	return nil
+}
+
+// ReadAll implements the GlobalReader interface
+func (s MemoryStore) ReadAll(_ context.Context, from int64, limit int) ([]Record, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	if from < 0 {
+		from = 0
+	}
+	if from >= int64(len(s.log.records)) {
+		return nil, nil
+	}
+	records := s.log.records[from:]
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return append([]Record(nil), records...), nil
+}
+
+// Head implements the GlobalReader interface
+func (s MemoryStore) Head(_ context.Context) (int64, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return int64(len(s.log.records)), nil
+}
+
+// Changed returns a channel that is closed the next time events are appended
+func (s MemoryStore) Changed() <-chan struct{} {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.log.changed
+}
+
+// Load implements the EventStore interface
+func (s MemoryStore) Load(_ context.Context, aggregateID uuid.UUID, fromVersion int) ([]events.Event, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	stream := s.streams[aggregateID]
+	if fromVersion < 0 {
+		fromVersion = 0
+	}
+	if fromVersion >= len(stream) {
+		return nil, nil
+	}
+	evs := make([]events.Event, len(stream)-fromVersion)
+	copy(evs, stream[fromVersion:])
+	return evs, nil
+}
@@ -0,0 +1,93 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type userAdded struct {
+	ID       uuid.UUID
+	UserName string
+}
+
+func (userAdded) Name() string { return "user_added" }
+
+func (e userAdded) AggregateID() uuid.UUID { return e.ID }
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		ctx = context.Background()
+		id  = uuid.New()
+		ev1 = userAdded{ID: id, UserName: "Bond"}
+		ev2 = userAdded{ID: id, UserName: "James Bond"}
+		ev3 = userAdded{ID: id, UserName: "007"}
+	)
+
+	t.Run(`Given a memory store, when events are appended with the expected version, then they are loaded in order`, func(t *testing.T) {
+		s := eventstore.NewMemoryStore()
+		require.NoError(t, s.Append(ctx, id, 0, ev1, ev2))
+		require.NoError(t, s.Append(ctx, id, 2, ev3))
+
+		evs, err := s.Load(ctx, id, 0)
+		require.NoError(t, err)
+		require.Equal(t, []events.Event{ev1, ev2, ev3}, evs)
+
+		evs, err = s.Load(ctx, id, 2)
+		require.NoError(t, err)
+		require.Equal(t, []events.Event{ev3}, evs)
+
+		evs, err = s.Load(ctx, uuid.New(), 0)
+		require.NoError(t, err)
+		require.Empty(t, evs)
+	})
+
+	t.Run(`Given a memory store, when events are appended with an outdated version, then a concurrency conflict is returned`, func(t *testing.T) {
+		s := eventstore.NewMemoryStore()
+		require.NoError(t, s.Append(ctx, id, 0, ev1))
+
+		err := s.Append(ctx, id, 0, ev2)
+		require.ErrorIs(t, err, eventstore.ErrConcurrencyConflict)
+		var conflictErr eventstore.ConcurrencyConflictError
+		require.True(t, errors.As(err, &conflictErr))
+		require.Equal(t, eventstore.ConcurrencyConflictError{AggregateID: id, Expected: 0, Actual: 1}, conflictErr)
+
+		evs, err := s.Load(ctx, id, 0)
+		require.NoError(t, err)
+		require.Equal(t, []events.Event{ev1}, evs)
+	})
+
+	t.Run(`Given a memory store, when all the events are read, then they are returned in the order they were appended`, func(t *testing.T) {
+		var (
+			s     = eventstore.NewMemoryStore()
+			other = userAdded{ID: uuid.New(), UserName: "Q"}
+		)
+		changed := s.Changed()
+		require.NoError(t, s.Append(ctx, id, 0, ev1))
+		<-changed
+		require.NoError(t, s.Append(ctx, other.ID, 0, other))
+		require.NoError(t, s.Append(ctx, id, 1, ev2))
+
+		records, err := s.ReadAll(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []eventstore.Record{
+			{Position: 1, AggregateID: id, Version: 1, Event: ev1},
+			{Position: 2, AggregateID: other.ID, Version: 1, Event: other},
+			{Position: 3, AggregateID: id, Version: 2, Event: ev2},
+		}, records)
+
+		records, err = s.ReadAll(ctx, 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, []eventstore.Record{{Position: 2, AggregateID: other.ID, Version: 1, Event: other}}, records)
+
+		head, err := s.Head(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), head)
+	})
+}
@@ -0,0 +1,243 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// SQLOpt is an option for the SQL event store constructor
+type SQLOpt func(*SQLStore)
+
+// WithTable sets the events table name. By default, it's events.
+func WithTable(table string) SQLOpt {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLOpt {
+	return func(s *SQLStore) {
+		s.ph = ph
+	}
+}
+
+// SQLStore is an event store on top of database/sql. It implements GlobalReader too.
+// It expects a table like this one, where position is auto-incremented by the DB
+// (AUTO_INCREMENT in MySQL, BIGSERIAL in PostgreSQL, AUTOINCREMENT in SQLite):
+//
+//	CREATE TABLE events (
+//		position     BIGINT AUTO_INCREMENT PRIMARY KEY,
+//		aggregate_id VARCHAR(36) NOT NULL,
+//		version      INTEGER NOT NULL,
+//		name         VARCHAR(255) NOT NULL,
+//		payload      BLOB NOT NULL,
+//		created_at   TIMESTAMP NOT NULL,
+//		UNIQUE (aggregate_id, version)
+//	)
+type SQLStore struct {
+	db    *sql.DB
+	codec Codec
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLStore is a constructor
+func NewSQLStore(db *sql.DB, codec Codec, opts ...SQLOpt) SQLStore {
+	s := SQLStore{
+		db:    db,
+		codec: codec,
+		table: "events",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Append implements the EventStore interface. The events are appended within the transaction
+// carried by ctx (see sqlx.WithTx), so they can be stored in the outbox too, or within a new one otherwise.
+// When two appends race for the same version, the unique key makes one of them fail.
+func (s SQLStore) Append(ctx context.Context, aggregateID uuid.UUID, expectedVersion int, evs ...events.Event) error {
+	if tx, ok := sqlx.TxFromContext(ctx); ok {
+		return s.append(ctx, tx, aggregateID, expectedVersion, evs)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.append(ctx, tx, aggregateID, expectedVersion, evs); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return s.conflictOr(ctx, s.db, aggregateID, expectedVersion, err)
+	}
+	return nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s SQLStore) version(ctx context.Context, q queryRower, aggregateID uuid.UUID) (int, error) {
+	var version int
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s WHERE aggregate_id = %s", s.table, s.ph(1))
+	err := q.QueryRowContext(ctx, query, aggregateID.String()).Scan(&version)
+	return version, err
+}
+
+// conflictOr returns a ConcurrencyConflictError if the aggregate version has changed, or err otherwise
+func (s SQLStore) conflictOr(ctx context.Context, q queryRower, aggregateID uuid.UUID, expectedVersion int, err error) error {
+	if version, vErr := s.version(ctx, q, aggregateID); vErr == nil && version != expectedVersion {
+		return ConcurrencyConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: version}
+	}
+	return err
+}
+
+func (s SQLStore) append(ctx context.Context, tx *sql.Tx, aggregateID uuid.UUID, expectedVersion int, evs []events.Event) error {
+	version, err := s.version(ctx, tx, aggregateID)
+	if err != nil {
+		return err
+	}
+	if version != expectedVersion {
+		return ConcurrencyConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: version}
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (aggregate_id, version, name, payload, created_at) VALUES (%s, %s, %s, %s, %s)",
+		s.table, s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5),
+	)
+	now := time.Now().UTC()
+	for i, e := range evs {
+		payload, err := s.codec.Encode(e)
+		if err != nil {
+			return fmt.Errorf("encoding event %s: %w", e.Name(), err)
+		}
+		if _, err := tx.ExecContext(ctx, query, aggregateID.String(), expectedVersion+i+1, e.Name(), payload, now); err != nil {
+			return s.conflictOr(ctx, s.db, aggregateID, expectedVersion, err)
+		}
+	}
+	return nil
+}
+
+// Load implements the EventStore interface
+func (s SQLStore) Load(ctx context.Context, aggregateID uuid.UUID, fromVersion int) ([]events.Event, error) {
+	query := fmt.Sprintf(
+		"SELECT version, name, payload FROM %s WHERE aggregate_id = %s AND version > %s ORDER BY version",
+		s.table, s.ph(1), s.ph(2),
+	)
+	rows, err := s.db.QueryContext(ctx, query, aggregateID.String(), fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evs []events.Event
+	for rows.Next() {
+		var (
+			version int
+			name    string
+			payload []byte
+		)
+		if err := rows.Scan(&version, &name, &payload); err != nil {
+			return nil, err
+		}
+		decoded, err := s.decode(name, payload, version)
+		if err != nil {
+			return nil, fmt.Errorf("decoding event %s: %w", name, err)
+		}
+		evs = append(evs, decoded...)
+	}
+	return evs, rows.Err()
+}
+
+// Head implements the GlobalReader interface
+func (s SQLStore) Head(ctx context.Context) (int64, error) {
+	var head int64
+	query := fmt.Sprintf("SELECT COALESCE(MAX(position), 0) FROM %s", s.table)
+	err := s.db.QueryRowContext(ctx, query).Scan(&head)
+	return head, err
+}
+
+// decode uses the codec DecodeAll method if it has it, like events.Registry, so the events can be upcast to several ones.
+// The aggregate version of the decoded events is the stored one, so all the events upcast from the same stored event
+// share it, and the aggregates rehydrated from them get the stored version, see ddd.EventSourcedAggregate.
+func (s SQLStore) decode(name string, payload []byte, version int) ([]events.Event, error) {
+	var evs []events.Event
+	if mc, ok := s.codec.(interface {
+		DecodeAll(name string, data []byte) ([]events.Event, error)
+	}); ok {
+		var err error
+		if evs, err = mc.DecodeAll(name, payload); err != nil {
+			return nil, err
+		}
+	} else {
+		e, err := s.codec.Decode(name, payload)
+		if err != nil {
+			return nil, err
+		}
+		evs = []events.Event{e}
+	}
+	for i, e := range evs {
+		evs[i], _ = events.UpdateMetadata(e, func(m events.Metadata) events.Metadata {
+			m.AggregateVersion = version
+			return m
+		})
+	}
+	return evs, nil
+}
+
+// ReadAll implements the GlobalReader interface. Take into account that concurrent transactions can commit
+// their positions out of order, so a reader can see a gap in the positions until a slow transaction commits,
+// or forever if it's rolled back. subscription.Subscription waits for the gaps to be filled, up to a timeout.
+func (s SQLStore) ReadAll(ctx context.Context, from int64, limit int) ([]Record, error) {
+	query := fmt.Sprintf(
+		"SELECT position, aggregate_id, version, name, payload FROM %s WHERE position > %s ORDER BY position",
+		s.table, s.ph(1),
+	)
+	args := []interface{}{from}
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %s", s.ph(2))
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var (
+			r           Record
+			aggregateID string
+			name        string
+			payload     []byte
+		)
+		if err := rows.Scan(&r.Position, &aggregateID, &r.Version, &name, &payload); err != nil {
+			return nil, err
+		}
+		if r.AggregateID, err = uuid.Parse(aggregateID); err != nil {
+			return nil, err
+		}
+		decoded, err := s.decode(name, payload, r.Version)
+		if err != nil {
+			return nil, fmt.Errorf("decoding event %s: %w", name, err)
+		}
+		for _, e := range decoded {
+			r.Event = e
+			records = append(records, r)
+		}
+	}
+	return records, rows.Err()
+}
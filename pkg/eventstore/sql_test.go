@@ -0,0 +1,185 @@
+package eventstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLStore(t *testing.T) {
+	var (
+		id           = uuid.New()
+		registry     = events.NewRegistry(events.JSONEncoder{})
+		ev           = userAdded{ID: id, UserName: "Bond"}
+		payload, _   = json.Marshal(ev)
+		versionQuery = regexp.QuoteMeta(`SELECT COALESCE(MAX(version), 0) FROM events WHERE aggregate_id = ?`)
+		insertQuery  = regexp.QuoteMeta(`INSERT INTO events (aggregate_id, version, name, payload, created_at) VALUES (?, ?, ?, ?, ?)`)
+	)
+	registry.Register(userAdded{})
+
+	t.Run(`Given a SQL store, when events are appended with the expected version, then they are inserted with the next versions`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(id.String()).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectExec(insertQuery).WithArgs(id.String(), 3, ev.Name(), payload, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).WithArgs(id.String(), 4, ev.Name(), payload, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, eventstore.NewSQLStore(db, registry).Append(context.Background(), id, 2, ev, ev))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when events are appended with an outdated version, then a concurrency conflict is returned`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(id.String()).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+		mock.ExpectRollback()
+
+		err = eventstore.NewSQLStore(db, registry).Append(context.Background(), id, 2, ev)
+		require.ErrorIs(t, err, eventstore.ErrConcurrencyConflict)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when a racing append inserts the same version first, then a concurrency conflict is returned`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("duplicate key"))
+		mock.ExpectQuery(versionQuery).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err = eventstore.NewSQLStore(db, registry).Append(context.Background(), id, 0, ev)
+		var conflictErr eventstore.ConcurrencyConflictError
+		require.True(t, errors.As(err, &conflictErr))
+		require.Equal(t, 1, conflictErr.Actual)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store and a context with a transaction, when events are appended, then they are inserted within it`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(version), 0) FROM stream WHERE aggregate_id = $1`)).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO stream (aggregate_id, version, name, payload, created_at) VALUES ($1, $2, $3, $4, $5)`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		s := eventstore.NewSQLStore(db, registry, eventstore.WithTable("stream"), eventstore.WithPlaceholder(sqlx.Dollar))
+		require.NoError(t, s.Append(sqlx.WithTx(context.Background(), tx), id, 0, ev))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the events of an aggregate are loaded, then they are decoded in order`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT version, name, payload FROM events WHERE aggregate_id = ? AND version > ? ORDER BY version`)).
+			WithArgs(id.String(), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "payload"}).AddRow(2, ev.Name(), payload))
+
+		evs, err := eventstore.NewSQLStore(db, registry).Load(context.Background(), id, 1)
+		require.NoError(t, err)
+		require.Equal(t, []events.Event{ev}, evs)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store with a codec with upcasters, when an old event is loaded, then it's upcast`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		upcasters := events.NewUpcasters()
+		upcasters.Register("user_created", 1, events.Rename("user_added"))
+		registry := events.NewRegistry(events.JSONEncoder{}, events.WithUpcasters(upcasters))
+		registry.Register(events.NewEventBasic(uuid.Nil, "user_added", nil))
+
+		old := events.NewEventBasic(id, "user_created", nil)
+		oldPayload, err := registry.Encode(old)
+		require.NoError(t, err)
+		mock.ExpectQuery(`SELECT version, name, payload FROM events`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "payload"}).AddRow(1, old.Name(), oldPayload))
+
+		evs, err := eventstore.NewSQLStore(db, registry).Load(context.Background(), id, 0)
+		require.NoError(t, err)
+		require.Len(t, evs, 1)
+		require.Equal(t, "user_added", evs[0].Name())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store with a codec that splits an old event, when it's loaded, then the split events keep its stored version`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		upcasters := events.NewUpcasters()
+		upcasters.Register("user_renamed", 1, func(e events.RawEvent) ([]events.RawEvent, error) {
+			first, last := e, e
+			first.Name, last.Name = "first_name_changed", "last_name_changed"
+			return []events.RawEvent{first, last}, nil
+		})
+		registry := events.NewRegistry(events.JSONEncoder{}, events.WithUpcasters(upcasters))
+		registry.Register(events.NewEventBasic(uuid.Nil, "first_name_changed", nil), events.NewEventBasic(uuid.Nil, "last_name_changed", nil))
+
+		old := events.NewEventBasic(id, "user_renamed", nil)
+		oldPayload, err := registry.Encode(old)
+		require.NoError(t, err)
+		mock.ExpectQuery(`SELECT version, name, payload FROM events`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "payload"}).AddRow(3, old.Name(), oldPayload))
+
+		evs, err := eventstore.NewSQLStore(db, registry).Load(context.Background(), id, 2)
+		require.NoError(t, err)
+		require.Len(t, evs, 2)
+		for _, e := range evs {
+			require.Equal(t, 3, e.(events.Enveloped).Metadata().AggregateVersion)
+		}
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when all the events are read, then they are returned by position`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT position, aggregate_id, version, name, payload FROM events WHERE position > ? ORDER BY position LIMIT ?`)).
+			WithArgs(10, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"position", "aggregate_id", "version", "name", "payload"}).
+				AddRow(11, id.String(), 3, ev.Name(), payload))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(position), 0) FROM events`)).
+			WillReturnRows(sqlmock.NewRows([]string{"head"}).AddRow(11))
+
+		s := eventstore.NewSQLStore(db, registry)
+		records, err := s.ReadAll(context.Background(), 10, 100)
+		require.NoError(t, err)
+		require.Equal(t, []eventstore.Record{{Position: 11, AggregateID: id, Version: 3, Event: ev}}, records)
+
+		head, err := s.Head(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(11), head)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
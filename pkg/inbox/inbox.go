@@ -0,0 +1,71 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoEventID is returned when the dispatchable doesn't have an event ID to be deduplicated by
+var ErrNoEventID = errors.New("no event ID")
+
+// Identifiable is an event with an unique ID, like events.EventBasic
+type Identifiable interface {
+	EventID() uuid.UUID
+}
+
+// Store records the events processed by each consumer
+type Store interface {
+	// Process calls f unless the event has already been processed by the consumer. The event is
+	// recorded as processed atomically with f, and only if f succeeds. It returns whether f has been called.
+	Process(ctx context.Context, consumer string, id uuid.UUID, f func(ctx context.Context) error) (bool, error)
+}
+
+// BusMw is a bus middleware that skips the events already handled by the consumer.
+// The dispatchables must implement Identifiable, otherwise ErrNoEventID is returned.
+// The response of a skipped event is nil.
+func BusMw(s Store, consumer string) bus.Middleware {
+	return func(h bus.Handler) bus.Handler {
+		return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+			e, ok := d.(Identifiable)
+			if !ok {
+				return nil, ErrNoEventID
+			}
+			var rs interface{}
+			_, err := s.Process(ctx, consumer, e.EventID(), func(ctx context.Context) error {
+				var err error
+				rs, err = h(ctx, d)
+				return err
+			})
+			return rs, err
+		}
+	}
+}
+
+// HandlerMw is an events handler middleware that skips the events already handled by the consumer.
+// Since events handlers don't return errors, the event is always recorded as processed after being
+// handled. Events that don't implement Identifiable are handled without deduplication.
+// The store errors are logged.
+func HandlerMw(s Store, consumer string) func(events.Handler) events.Handler {
+	return func(h events.Handler) events.Handler {
+		return func(e events.Event) {
+			ie, ok := e.(Identifiable)
+			if !ok {
+				h(e)
+				return
+			}
+			_, err := s.Process(context.Background(), consumer, ie.EventID(), func(context.Context) error {
+				h(e)
+				return nil
+			})
+			if err != nil {
+				log.Printf("inbox for %s: event %s: %s", consumer, ie.EventID(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package inbox_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/inbox"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type command struct{}
+
+func (command) Name() string { return "command" }
+
+func TestBusMw(t *testing.T) {
+	t.Run(`Given a bus with an inbox middleware, when an event is dispatched twice, then it's handled once`, func(t *testing.T) {
+		var (
+			calls int
+			ev    = events.NewEventBasic(uuid.New(), "user_added", nil)
+			b     = bus.New()
+		)
+		b.Register(ev.Name(), func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			calls++
+			return d, nil
+		}, inbox.BusMw(inbox.NewMemoryStore(0, 0), "consumer"))
+
+		rs, err := b.Dispatch(context.Background(), ev)
+		require.NoError(t, err)
+		require.Equal(t, ev, rs)
+
+		rs, err = b.Dispatch(context.Background(), ev)
+		require.NoError(t, err)
+		require.Nil(t, rs)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run(`Given a bus with an inbox middleware, when a dispatchable without ID is dispatched, then an error is returned`, func(t *testing.T) {
+		b := bus.New()
+		b.Register(command{}.Name(), func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+			return nil, nil
+		}, inbox.BusMw(inbox.NewMemoryStore(0, 0), "consumer"))
+
+		_, err := b.Dispatch(context.Background(), command{})
+		require.ErrorIs(t, err, inbox.ErrNoEventID)
+	})
+}
+
+func TestHandlerMw(t *testing.T) {
+	t.Run(`Given an events handler with an inbox middleware, when an event is handled twice, then it's handled once`, func(t *testing.T) {
+		var (
+			calls int
+			ev    = events.NewEventBasic(uuid.New(), "user_added", nil)
+			h     = inbox.HandlerMw(inbox.NewMemoryStore(0, 0), "consumer")(func(events.Event) {
+				calls++
+			})
+		)
+		h(ev)
+		h(ev)
+		h(events.NewEventBasic(uuid.New(), "user_added", nil))
+		require.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,116 @@
+package inbox
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type key struct {
+	consumer string
+	id       uuid.UUID
+}
+
+type entry struct {
+	k  key
+	at time.Time
+}
+
+// MemoryStore is an in-memory inbox store. It keeps the most recently processed events up to
+// its capacity, evicting the least recently processed ones, and forgets them once their TTL has expired.
+type MemoryStore struct {
+	mux      *sync.Mutex
+	capacity int
+	ttl      time.Duration
+	lru      *list.List
+	entries  map[key]*list.Element
+	inFlight map[key]chan struct{}
+	now      func() time.Time
+}
+
+// NewMemoryStore is a constructor. A capacity or a ttl of 0 means no limit.
+func NewMemoryStore(capacity int, ttl time.Duration) MemoryStore {
+	return MemoryStore{
+		mux:      &sync.Mutex{},
+		capacity: capacity,
+		ttl:      ttl,
+		lru:      list.New(),
+		entries:  make(map[key]*list.Element),
+		inFlight: make(map[key]chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Process implements the Store interface. A duplicated event that arrives while the first one
+// is being processed waits for it to finish.
+func (s MemoryStore) Process(ctx context.Context, consumer string, id uuid.UUID, f func(ctx context.Context) error) (bool, error) {
+	k := key{consumer: consumer, id: id}
+	for {
+		s.mux.Lock()
+		if s.seen(k) {
+			s.mux.Unlock()
+			return false, nil
+		}
+		done, ok := s.inFlight[k]
+		if !ok {
+			break
+		}
+		s.mux.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	s.inFlight[k] = done
+	s.mux.Unlock()
+
+	// The event is released even if f panics, so its redeliveries don't wait for it forever
+	defer func() {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		delete(s.inFlight, k)
+		close(done)
+	}()
+	if err := f(ctx); err != nil {
+		return true, err
+	}
+
+	s.mux.Lock()
+	s.add(k)
+	s.mux.Unlock()
+	return true, nil
+}
+
+// Len returns the number of recorded events
+func (s MemoryStore) Len() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.lru.Len()
+}
+
+func (s MemoryStore) seen(k key) bool {
+	el, ok := s.entries[k]
+	if !ok {
+		return false
+	}
+	if s.ttl > 0 && s.now().Sub(el.Value.(entry).at) >= s.ttl {
+		s.lru.Remove(el)
+		delete(s.entries, k)
+		return false
+	}
+	return true
+}
+
+func (s MemoryStore) add(k key) {
+	s.entries[k] = s.lru.PushFront(entry{k: k, at: s.now()})
+	for s.capacity > 0 && s.lru.Len() > s.capacity {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(entry).k)
+	}
+}
@@ -0,0 +1,125 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/inbox"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		noop = func(context.Context) error { return nil }
+	)
+
+	t.Run(`Given a memory store, when an event is processed twice by the same consumer, then it's only processed once`, func(t *testing.T) {
+		s := inbox.NewMemoryStore(0, 0)
+		id := uuid.New()
+
+		processed, err := s.Process(ctx, "consumer", id, noop)
+		require.NoError(t, err)
+		require.True(t, processed)
+
+		processed, err = s.Process(ctx, "consumer", id, noop)
+		require.NoError(t, err)
+		require.False(t, processed)
+
+		processed, err = s.Process(ctx, "another consumer", id, noop)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run(`Given a memory store, when processing an event fails, then it's not recorded`, func(t *testing.T) {
+		s := inbox.NewMemoryStore(0, 0)
+		id := uuid.New()
+		randomErr := errors.New("")
+
+		processed, err := s.Process(ctx, "consumer", id, func(context.Context) error { return randomErr })
+		require.ErrorIs(t, err, randomErr)
+		require.True(t, processed)
+
+		processed, err = s.Process(ctx, "consumer", id, noop)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run(`Given a full memory store, when a new event is processed, then the least recently processed one is evicted`, func(t *testing.T) {
+		s := inbox.NewMemoryStore(2, 0)
+		ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+		for _, id := range ids {
+			_, err := s.Process(ctx, "consumer", id, noop)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, s.Len())
+
+		processed, err := s.Process(ctx, "consumer", ids[2], noop)
+		require.NoError(t, err)
+		require.False(t, processed)
+
+		processed, err = s.Process(ctx, "consumer", ids[0], noop)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run(`Given a memory store with a TTL, when an event is processed again after it, then it's processed`, func(t *testing.T) {
+		s := inbox.NewMemoryStore(0, 20*time.Millisecond)
+		id := uuid.New()
+
+		_, err := s.Process(ctx, "consumer", id, noop)
+		require.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+
+		processed, err := s.Process(ctx, "consumer", id, noop)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run(`Given a memory store, when an event is processed concurrently, then it's only processed once`, func(t *testing.T) {
+		var (
+			s     = inbox.NewMemoryStore(0, 0)
+			id    = uuid.New()
+			mux   sync.Mutex
+			calls int
+			wg    sync.WaitGroup
+		)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := s.Process(ctx, "consumer", id, func(context.Context) error {
+					mux.Lock()
+					defer mux.Unlock()
+					calls++
+					return nil
+				})
+				require.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run(`Given a memory store, when processing an event panics, then its redelivery is processed`, func(t *testing.T) {
+		s := inbox.NewMemoryStore(0, 0)
+		id := uuid.New()
+
+		require.Panics(t, func() {
+			_, _ = s.Process(ctx, "consumer", id, func(context.Context) error {
+				panic("handler failed")
+			})
+		})
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		processed, err := s.Process(ctx, "consumer", id, noop)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+}
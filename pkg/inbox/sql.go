@@ -0,0 +1,95 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// SQLOpt is an option for the SQL inbox store constructor
+type SQLOpt func(*SQLStore)
+
+// WithTable sets the inbox table name. By default, it's inbox.
+func WithTable(table string) SQLOpt {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLOpt {
+	return func(s *SQLStore) {
+		s.ph = ph
+	}
+}
+
+// SQLStore is an inbox store on top of database/sql. It expects a table like this one:
+//
+//	CREATE TABLE inbox (
+//		consumer     VARCHAR(255) NOT NULL,
+//		event_id     VARCHAR(36) NOT NULL,
+//		processed_at TIMESTAMP NOT NULL,
+//		PRIMARY KEY (consumer, event_id)
+//	)
+type SQLStore struct {
+	db    *sql.DB
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLStore is a constructor
+func NewSQLStore(db *sql.DB, opts ...SQLOpt) SQLStore {
+	s := SQLStore{
+		db:    db,
+		table: "inbox",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Process implements the Store interface. The event is recorded within the transaction carried
+// by ctx (see sqlx.WithTx), or within a new one otherwise. In the last case, f receives the transaction
+// through its context, so the handler's work can be done in it, and it's committed only if f succeeds.
+// When a duplicated event is processed concurrently, the primary key makes one of them fail, and
+// it's rollbacked.
+func (s SQLStore) Process(ctx context.Context, consumer string, id uuid.UUID, f func(ctx context.Context) error) (bool, error) {
+	if tx, ok := sqlx.TxFromContext(ctx); ok {
+		return s.process(ctx, tx, consumer, id, f)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	processed, err := s.process(sqlx.WithTx(ctx, tx), tx, consumer, id, f)
+	if err != nil || !processed {
+		_ = tx.Rollback()
+		return processed, err
+	}
+	return true, tx.Commit()
+}
+
+func (s SQLStore) process(ctx context.Context, tx *sql.Tx, consumer string, id uuid.UUID, f func(ctx context.Context) error) (bool, error) {
+	var n int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE consumer = %s AND event_id = %s", s.table, s.ph(1), s.ph(2))
+	if err := tx.QueryRowContext(ctx, query, consumer, id.String()).Scan(&n); err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, nil
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (consumer, event_id, processed_at) VALUES (%s, %s, %s)", s.table, s.ph(1), s.ph(2), s.ph(3))
+	if _, err := tx.ExecContext(ctx, query, consumer, id.String(), time.Now().UTC()); err != nil {
+		return false, err
+	}
+	return true, f(ctx)
+}
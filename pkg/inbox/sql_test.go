@@ -0,0 +1,104 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/inbox"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLStore(t *testing.T) {
+	var (
+		id          = uuid.New()
+		selectQuery = regexp.QuoteMeta(`SELECT COUNT(*) FROM inbox WHERE consumer = ? AND event_id = ?`)
+		insertQuery = regexp.QuoteMeta(`INSERT INTO inbox (consumer, event_id, processed_at) VALUES (?, ?, ?)`)
+	)
+
+	t.Run(`Given a SQL store, when a new event is processed, then it's recorded within the handler transaction`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).WithArgs("consumer", id.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(insertQuery).WithArgs("consumer", id.String(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		processed, err := inbox.NewSQLStore(db).Process(context.Background(), "consumer", id, func(ctx context.Context) error {
+			_, ok := sqlx.TxFromContext(ctx)
+			require.True(t, ok)
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, processed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when an already processed event is processed, then it's skipped`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).WithArgs("consumer", id.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		processed, err := inbox.NewSQLStore(db).Process(context.Background(), "consumer", id, func(context.Context) error {
+			t.Fail()
+			return nil
+		})
+		require.NoError(t, err)
+		require.False(t, processed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the handler fails, then the transaction is rollbacked`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		randomErr := errors.New("")
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(insertQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		_, err = inbox.NewSQLStore(db).Process(context.Background(), "consumer", id, func(context.Context) error {
+			return randomErr
+		})
+		require.ErrorIs(t, err, randomErr)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store and a context with a transaction, when an event is processed, then it's recorded within that transaction`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM consumed WHERE consumer = $1 AND event_id = $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO consumed (consumer, event_id, processed_at) VALUES ($1, $2, $3)`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		s := inbox.NewSQLStore(db, inbox.WithTable("consumed"), inbox.WithPlaceholder(sqlx.Dollar))
+		processed, err := s.Process(sqlx.WithTx(context.Background(), tx), "consumer", id, func(context.Context) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, processed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
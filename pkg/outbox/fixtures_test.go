@@ -0,0 +1,84 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/outbox"
+
+	"github.com/google/uuid"
+)
+
+type userAdded struct {
+	ID       uuid.UUID
+	UserName string
+}
+
+func (userAdded) Name() string { return "user_added" }
+
+func (e userAdded) AggregateID() uuid.UUID { return e.ID }
+
+// memOutbox is an in-memory outbox for testing the relay
+type memOutbox struct {
+	mux     sync.Mutex
+	records []outbox.Record
+	sent    map[uuid.UUID]bool
+	retryAt map[uuid.UUID]time.Time
+}
+
+func newMemOutbox(evs ...events.Event) *memOutbox {
+	o := &memOutbox{sent: make(map[uuid.UUID]bool), retryAt: make(map[uuid.UUID]time.Time)}
+	for _, e := range evs {
+		o.records = append(o.records, outbox.Record{ID: uuid.New(), Event: e})
+	}
+	return o
+}
+
+func (o *memOutbox) Store(_ context.Context, _ *sql.Tx, evs ...events.Event) error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	for _, e := range evs {
+		o.records = append(o.records, outbox.Record{ID: uuid.New(), Event: e})
+	}
+	return nil
+}
+
+func (o *memOutbox) Unsent(_ context.Context, now time.Time, limit int) ([]outbox.Record, error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	var unsent []outbox.Record
+	for _, r := range o.records {
+		if !o.sent[r.ID] && !o.retryAt[r.ID].After(now) && len(unsent) < limit {
+			unsent = append(unsent, r)
+		}
+	}
+	return unsent, nil
+}
+
+func (o *memOutbox) MarkSent(_ context.Context, id uuid.UUID) error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.sent[id] = true
+	return nil
+}
+
+func (o *memOutbox) MarkFailed(_ context.Context, id uuid.UUID, attempts int, retryAt time.Time) error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	for i, r := range o.records {
+		if r.ID == id {
+			o.records[i].Attempts = attempts
+		}
+	}
+	o.retryAt[id] = retryAt
+	return nil
+}
+
+func (o *memOutbox) sentCount() int {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	return len(o.sent)
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoTx is returned when there is no transaction in the context to store the events
+var ErrNoTx = errors.New("no transaction in context")
+
+// Codec encodes and decodes events to be persisted
+type Codec interface {
+	Encode(e events.Event) ([]byte, error)
+	Decode(name string, data []byte) (events.Event, error)
+}
+
+// Record is an event stored in the outbox
+type Record struct {
+	ID        uuid.UUID
+	Event     events.Event
+	CreatedAt time.Time
+	// Attempts is the number of times it has failed to be relayed
+	Attempts int
+	// Err is the error decoding the event, if it can't be decoded. Then, Event is nil.
+	Err error
+}
+
+// Outbox keeps the events to be published, stored in the same transaction as the changes that raised them
+type Outbox interface {
+	// Store adds the events to the outbox within the given transaction
+	Store(ctx context.Context, tx *sql.Tx, evs ...events.Event) error
+	// Unsent returns up to limit events not delivered yet, in the order they were stored,
+	// skipping the ones that failed and have to be retried after now
+	Unsent(ctx context.Context, now time.Time, limit int) ([]Record, error)
+	// MarkSent marks an event as delivered
+	MarkSent(ctx context.Context, id uuid.UUID) error
+	// MarkFailed sets the failed attempts to relay an event, and when it has to be retried
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, retryAt time.Time) error
+}
+
+// TxMw is a command handler middleware that runs the wrapped command handler within a DB transaction.
+// The transaction is passed through the context, see sqlx.TxFromContext. It's committed if the command handler
+// succeeds, and rollbacked otherwise.
+func TxMw(db *sql.DB) cqrs.CommandHandlerMiddleware {
+	return func(ch cqrs.CommandHandler) cqrs.CommandHandler {
+		return cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) ([]events.Event, error) {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			evs, err := ch.Handle(sqlx.WithTx(ctx, tx), cmd)
+			if err != nil {
+				_ = tx.Rollback()
+				return evs, err
+			}
+			return evs, tx.Commit()
+		})
+	}
+}
+
+// ChMw is a command handler middleware that stores the events returned by the wrapped command handler
+// into the outbox, within the transaction carried by the context. It must be wrapped by TxMw,
+// or by any other middleware that puts the transaction in the context with sqlx.WithTx.
+// Like cqrs.ChEventMw, it propagates the correlation and causation IDs of the context to the events,
+// but they are not dispatched. A Relay does it once the transaction has been committed.
+func ChMw(o Outbox) cqrs.CommandHandlerMiddleware {
+	return func(ch cqrs.CommandHandler) cqrs.CommandHandler {
+		return cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) ([]events.Event, error) {
+			ctx = cqrs.CorrelatedContext(ctx)
+			evs, err := ch.Handle(ctx, cmd)
+			if err != nil || len(evs) == 0 {
+				return evs, err
+			}
+			propagated := make([]events.Event, len(evs))
+			for i, e := range evs {
+				propagated[i] = events.Propagate(ctx, e)
+			}
+			tx, ok := sqlx.TxFromContext(ctx)
+			if !ok {
+				return propagated, ErrNoTx
+			}
+			return propagated, o.Store(ctx, tx, propagated...)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/outbox"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type addUserCmd struct{}
+
+func (addUserCmd) Name() string { return "add_user" }
+
+func TestChMw(t *testing.T) {
+	ev := userAdded{ID: uuid.New(), UserName: "Bond"}
+	registry := events.NewRegistry(events.JSONEncoder{})
+	registry.Register(ev)
+
+	t.Run(`Given a command handler wrapped by the tx and outbox middlewares,
+		when it succeeds,
+		then its events are stored in the outbox within the committed transaction`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ch := cqrs.CommandHandlerMultiMiddleware(
+			outbox.ChMw(outbox.NewSQLOutbox(db, registry)),
+			outbox.TxMw(db),
+		)(cqrs.CommandHandlerFunc(func(ctx context.Context, _ cqrs.Command) ([]events.Event, error) {
+			_, ok := sqlx.TxFromContext(ctx)
+			require.True(t, ok)
+			return []events.Event{ev}, nil
+		}))
+
+		evs, err := ch.Handle(context.Background(), addUserCmd{})
+		require.NoError(t, err)
+		require.Equal(t, []events.Event{ev}, evs)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a command handler wrapped by the tx and outbox middlewares,
+		when it fails,
+		then nothing is stored and the transaction is rollbacked`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		randomErr := errors.New("")
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		ch := cqrs.CommandHandlerMultiMiddleware(
+			outbox.ChMw(outbox.NewSQLOutbox(db, registry)),
+			outbox.TxMw(db),
+		)(cqrs.CommandHandlerFunc(func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+			return []events.Event{ev}, randomErr
+		}))
+
+		_, err = ch.Handle(context.Background(), addUserCmd{})
+		require.ErrorIs(t, err, randomErr)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a command handler wrapped by the outbox middleware without a transaction,
+		when it returns events,
+		then an error is returned`, func(t *testing.T) {
+		ch := outbox.ChMw(newMemOutbox())(cqrs.CommandHandlerFunc(func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+			return []events.Event{ev}, nil
+		}))
+
+		_, err := ch.Handle(context.Background(), addUserCmd{})
+		require.ErrorIs(t, err, outbox.ErrNoTx)
+	})
+
+	t.Run(`Given a command handler wrapped by the outbox middleware,
+		when it returns events,
+		then they're returned with the correlation ID, but its slice is not modified`, func(t *testing.T) {
+		handled := []events.Event{events.NewEventBasic(uuid.New(), "user.added", nil)}
+		ch := outbox.ChMw(newMemOutbox())(cqrs.CommandHandlerFunc(func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+			return handled, nil
+		}))
+
+		evs, err := ch.Handle(context.Background(), addUserCmd{})
+		require.ErrorIs(t, err, outbox.ErrNoTx)
+		require.NotEqual(t, uuid.Nil, evs[0].(events.Enveloped).Metadata().CorrelationID)
+		require.Equal(t, uuid.Nil, handled[0].(events.Enveloped).Metadata().CorrelationID)
+	})
+}
@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/deadletter"
+
+	"github.com/google/uuid"
+)
+
+// RelayOpt is an option for the relay constructor
+type RelayOpt func(*Relay)
+
+// WithBackoff sets how long a failed event waits to be relayed again. The wait doubles on each failed attempt,
+// from initial up to maxBackoff. By default, it's 1 second and 1 hour.
+func WithBackoff(initial, maxBackoff time.Duration) RelayOpt {
+	return func(r *Relay) {
+		r.initialBackoff = initial
+		r.maxBackoff = maxBackoff
+	}
+}
+
+// WithDeadLetters sets the store where the events that have failed to be dispatched maxAttempts times are moved to.
+// Then, they're marked as sent, so they're not relayed again. By default, the failed events are retried forever.
+func WithDeadLetters(store deadletter.Store, maxAttempts int) RelayOpt {
+	return func(r *Relay) {
+		r.deadLetters = store
+		r.maxAttempts = maxAttempts
+	}
+}
+
+// WithClock sets the function that returns the current time. By default, it's time.Now.
+func WithClock(now func() time.Time) RelayOpt {
+	return func(r *Relay) {
+		r.now = now
+	}
+}
+
+// Relay polls the outbox for unsent events, dispatches them and marks them as delivered.
+// An event is marked only after it has been dispatched, so it's delivered at least once.
+type Relay struct {
+	o         Outbox
+	d         bus.Dispatcher
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	deadLetters    deadletter.Store
+	maxAttempts    int
+}
+
+// NewRelay is a constructor
+func NewRelay(o Outbox, d bus.Dispatcher, interval time.Duration, batchSize int, opts ...RelayOpt) Relay {
+	r := Relay{
+		o:         o,
+		d:         d,
+		interval:  interval,
+		batchSize: batchSize,
+		now:       time.Now,
+
+		initialBackoff: time.Second,
+		maxBackoff:     time.Hour,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// RelayOnce relays a batch of unsent events, in the order they were stored. If an event can't be decoded,
+// or fails to be dispatched, it's retried after a backoff (see WithBackoff), or moved to the dead letters
+// (see WithDeadLetters), and the next ones are relayed anyway, so a bad event doesn't block the outbox.
+// It returns the number of relayed events, and the first error.
+func (r Relay) RelayOnce(ctx context.Context) (int, error) {
+	now := r.now()
+	records, err := r.o.Unsent(ctx, now, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		n        int
+		firstErr error
+	)
+	for _, rec := range records {
+		if err := r.relay(ctx, now, rec); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		n++
+	}
+	return n, firstErr
+}
+
+func (r Relay) relay(ctx context.Context, now time.Time, rec Record) error {
+	if rec.Err != nil {
+		return r.failed(ctx, now, rec, fmt.Errorf("outbox event %s: %w", rec.ID, rec.Err))
+	}
+	if _, err := r.d.Dispatch(ctx, rec.Event); err != nil {
+		return r.failed(ctx, now, rec, fmt.Errorf("dispatching outbox event %s: %w", rec.ID, err))
+	}
+	if err := r.o.MarkSent(ctx, rec.ID); err != nil {
+		return fmt.Errorf("marking outbox event %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// failed records a failed attempt to relay an event, to be retried after its backoff, or moves it to the dead letters
+// once it has reached the max attempts. The events that can't be decoded can't be moved, so they're retried.
+// It returns the relay error.
+func (r Relay) failed(ctx context.Context, now time.Time, rec Record, err error) error {
+	attempts := rec.Attempts + 1
+	if r.deadLetters != nil && rec.Event != nil && attempts >= r.maxAttempts {
+		dl := deadletter.DeadLetter{
+			ID:            uuid.New(),
+			Dispatchable:  rec.Event,
+			Handler:       "outbox",
+			Err:           err.Error(),
+			Attempts:      attempts,
+			FirstFailedAt: now,
+			LastFailedAt:  now,
+		}
+		if saveErr := r.deadLetters.Save(ctx, dl); saveErr != nil {
+			return fmt.Errorf("%w (saving dead letter: %s)", err, saveErr.Error())
+		}
+		if markErr := r.o.MarkSent(ctx, rec.ID); markErr != nil {
+			return fmt.Errorf("%w (marking outbox event: %s)", err, markErr.Error())
+		}
+		return err
+	}
+	if markErr := r.o.MarkFailed(ctx, rec.ID, attempts, now.Add(r.backoff(attempts))); markErr != nil {
+		return fmt.Errorf("%w (marking outbox event: %s)", err, markErr.Error())
+	}
+	return err
+}
+
+// backoff returns the wait before the attempt n+1
+func (r Relay) backoff(n int) time.Duration {
+	b := r.initialBackoff
+	for i := 1; i < n && b < r.maxBackoff; i++ {
+		b *= 2
+	}
+	if b > r.maxBackoff {
+		return r.maxBackoff
+	}
+	return b
+}
+
+// Run relays the unsent events until ctx is done. When a batch is full, the next one is relayed
+// without waiting. Errors are sent to errCh, if it's not nil.
+func (r Relay) Run(ctx context.Context, errCh chan<- error) {
+	timer := time.NewTimer(r.interval)
+	defer timer.Stop()
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil && errCh != nil && ctx.Err() == nil {
+			select {
+			case errCh <- err:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err == nil && n == r.batchSize {
+			continue
+		}
+		resetTimer(timer, r.interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// resetTimer stops the timer, draining its channel if it had fired, and resets it
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
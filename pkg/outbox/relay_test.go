@@ -0,0 +1,192 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/deadletter"
+	"github.com/theskyinflames/cqrs-eda/pkg/outbox"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelay(t *testing.T) {
+	var (
+		ev1 = userAdded{ID: uuid.New(), UserName: "Bond"}
+		ev2 = userAdded{ID: uuid.New(), UserName: "Moneypenny"}
+		ev3 = userAdded{ID: uuid.New(), UserName: "Q"}
+	)
+
+	t.Run(`Given an outbox with unsent events,
+		when they are relayed to a bus,
+		then they are dispatched in order and marked as sent`, func(t *testing.T) {
+		var (
+			o          = newMemOutbox(ev1, ev2, ev3)
+			b          = bus.New()
+			dispatched []bus.Dispatchable
+		)
+		b.Register(ev1.Name(), func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			dispatched = append(dispatched, d)
+			return nil, nil
+		})
+
+		r := outbox.NewRelay(o, b, time.Hour, 2)
+		n, err := r.RelayOnce(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		n, err = r.RelayOnce(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		require.Equal(t, []bus.Dispatchable{ev1, ev2, ev3}, dispatched)
+		require.Equal(t, 3, o.sentCount())
+	})
+
+	t.Run(`Given an outbox with unsent events,
+		when one of them fails to be dispatched,
+		then it's retried after a backoff, and the next ones are relayed`, func(t *testing.T) {
+		var (
+			o         = newMemOutbox(ev1, ev2, ev3)
+			b         = bus.New()
+			randomErr = errors.New("")
+			now       = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			failing   = true
+		)
+		b.Register(ev1.Name(), func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			if d == ev2 && failing {
+				return nil, randomErr
+			}
+			return nil, nil
+		})
+		r := outbox.NewRelay(o, b, time.Hour, 10, outbox.WithBackoff(time.Minute, time.Hour), outbox.WithClock(func() time.Time { return now }))
+
+		n, err := r.RelayOnce(context.Background())
+		require.ErrorIs(t, err, randomErr)
+		require.Equal(t, 2, n)
+
+		unsent, err := o.Unsent(context.Background(), now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, unsent, 1)
+		require.Equal(t, ev2, unsent[0].Event)
+		require.Equal(t, 1, unsent[0].Attempts)
+
+		n, err = r.RelayOnce(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		failing = false
+		now = now.Add(time.Minute)
+		n, err = r.RelayOnce(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, 3, o.sentCount())
+	})
+
+	t.Run(`Given failing events that fill a batch,
+		when the outbox is relayed again,
+		then the next events are relayed`, func(t *testing.T) {
+		var (
+			o          = newMemOutbox(ev1, ev2, ev3)
+			b          = bus.New()
+			randomErr  = errors.New("")
+			dispatched []bus.Dispatchable
+		)
+		b.Register(ev1.Name(), func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			if d != ev3 {
+				return nil, randomErr
+			}
+			dispatched = append(dispatched, d)
+			return nil, nil
+		})
+		r := outbox.NewRelay(o, b, time.Hour, 2)
+
+		n, err := r.RelayOnce(context.Background())
+		require.ErrorIs(t, err, randomErr)
+		require.Zero(t, n)
+
+		n, err = r.RelayOnce(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, []bus.Dispatchable{ev3}, dispatched)
+	})
+
+	t.Run(`Given an event that can't be decoded,
+		when the outbox is relayed,
+		then it's skipped and retried after a backoff`, func(t *testing.T) {
+		var (
+			o         = newMemOutbox(ev1)
+			decodeErr = errors.New("")
+			b         = bus.New()
+		)
+		o.records = append([]outbox.Record{{ID: uuid.New(), Err: decodeErr}}, o.records...)
+		b.Register(ev1.Name(), func(context.Context, bus.Dispatchable) (interface{}, error) {
+			return nil, nil
+		})
+
+		n, err := outbox.NewRelay(o, b, time.Hour, 10).RelayOnce(context.Background())
+		require.ErrorIs(t, err, decodeErr)
+		require.Equal(t, 1, n)
+		require.Equal(t, 1, o.sentCount())
+
+		unsent, err := o.Unsent(context.Background(), time.Now(), 10)
+		require.NoError(t, err)
+		require.Empty(t, unsent)
+	})
+
+	t.Run(`Given a dead letter store,
+		when an event fails to be dispatched the max attempts,
+		then it's moved to it`, func(t *testing.T) {
+		var (
+			o           = newMemOutbox(ev1)
+			deadLetters = deadletter.NewMemoryStore()
+			randomErr   = errors.New("")
+			b           = bus.New()
+			now         = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+		)
+		b.Register(ev1.Name(), func(context.Context, bus.Dispatchable) (interface{}, error) {
+			return nil, randomErr
+		})
+		r := outbox.NewRelay(o, b, time.Hour, 10, outbox.WithBackoff(time.Minute, time.Minute),
+			outbox.WithDeadLetters(deadLetters, 2), outbox.WithClock(func() time.Time { return now }))
+
+		for i := 0; i < 2; i++ {
+			_, err := r.RelayOnce(context.Background())
+			require.ErrorIs(t, err, randomErr)
+			now = now.Add(time.Minute)
+		}
+		require.Equal(t, 1, o.sentCount())
+
+		dls, err := deadLetters.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		require.Equal(t, ev1, dls[0].Dispatchable)
+		require.Equal(t, 2, dls[0].Attempts)
+	})
+
+	t.Run(`Given a running relay to a concurrent bus,
+		when events are stored in the outbox,
+		then they are eventually delivered`, func(t *testing.T) {
+		var (
+			o         = newMemOutbox()
+			cbus      = bus.NewConcurrentBus(time.Second, 1)
+			delivered = make(chan bus.Dispatchable, 2)
+		)
+		cbus.Register(ev1.Name(), func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			delivered <- d
+			return nil, nil
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cbus.Run(ctx)
+		go outbox.NewRelay(o, bus.Await(cbus), time.Millisecond, 10).Run(ctx, nil)
+
+		require.NoError(t, o.Store(ctx, nil, ev1, ev2))
+		require.Equal(t, ev1, <-delivered)
+		require.Equal(t, ev2, <-delivered)
+		require.Eventually(t, func() bool { return o.sentCount() == 2 }, time.Second, time.Millisecond)
+	})
+}
@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// SQLOpt is an option for the SQL outbox constructor
+type SQLOpt func(*SQLOutbox)
+
+// WithTable sets the outbox table name. By default, it's outbox.
+func WithTable(table string) SQLOpt {
+	return func(o *SQLOutbox) {
+		o.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLOpt {
+	return func(o *SQLOutbox) {
+		o.ph = ph
+	}
+}
+
+// SQLOutbox is an Outbox on top of database/sql. It expects a table like this one, where seq is
+// auto-incremented by the DB (AUTO_INCREMENT in MySQL, BIGSERIAL in PostgreSQL, AUTOINCREMENT in SQLite),
+// so the events are relayed in the order they were stored:
+//
+//	CREATE TABLE outbox (
+//		seq          BIGINT AUTO_INCREMENT PRIMARY KEY,
+//		id           VARCHAR(36) NOT NULL UNIQUE,
+//		aggregate_id VARCHAR(36) NOT NULL,
+//		name         VARCHAR(255) NOT NULL,
+//		payload      BLOB NOT NULL,
+//		created_at   TIMESTAMP NOT NULL,
+//		sent_at      TIMESTAMP NULL,
+//		attempts     INT NOT NULL DEFAULT 0,
+//		retry_at     TIMESTAMP NULL
+//	)
+type SQLOutbox struct {
+	db    *sql.DB
+	codec Codec
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLOutbox is a constructor
+func NewSQLOutbox(db *sql.DB, codec Codec, opts ...SQLOpt) SQLOutbox {
+	o := SQLOutbox{
+		db:    db,
+		codec: codec,
+		table: "outbox",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Store implements the Outbox interface
+func (o SQLOutbox) Store(ctx context.Context, tx *sql.Tx, evs ...events.Event) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (id, aggregate_id, name, payload, created_at) VALUES (%s, %s, %s, %s, %s)",
+		o.table, o.ph(1), o.ph(2), o.ph(3), o.ph(4), o.ph(5),
+	)
+	now := time.Now().UTC()
+	for _, e := range evs {
+		payload, err := o.codec.Encode(e)
+		if err != nil {
+			return fmt.Errorf("encoding event %s: %w", e.Name(), err)
+		}
+		if _, err := tx.ExecContext(ctx, query, uuid.New().String(), e.AggregateID().String(), e.Name(), payload, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unsent implements the Outbox interface. The events that can't be decoded are returned with the decoding error,
+// so the relay can skip them.
+func (o SQLOutbox) Unsent(ctx context.Context, now time.Time, limit int) ([]Record, error) {
+	query := fmt.Sprintf(
+		"SELECT id, name, payload, created_at, attempts FROM %s WHERE sent_at IS NULL AND (retry_at IS NULL OR retry_at <= %s) ORDER BY seq LIMIT %s",
+		o.table, o.ph(1), o.ph(2),
+	)
+	rows, err := o.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var (
+			id, name string
+			payload  []byte
+			r        Record
+		)
+		if err := rows.Scan(&id, &name, &payload, &r.CreatedAt, &r.Attempts); err != nil {
+			return nil, err
+		}
+		if r.ID, err = uuid.Parse(id); err != nil {
+			return nil, err
+		}
+		if r.Event, err = o.codec.Decode(name, payload); err != nil {
+			r.Err = fmt.Errorf("decoding event %s: %w", name, err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// MarkSent implements the Outbox interface
+func (o SQLOutbox) MarkSent(ctx context.Context, id uuid.UUID) error {
+	query := fmt.Sprintf("UPDATE %s SET sent_at = %s WHERE id = %s", o.table, o.ph(1), o.ph(2))
+	_, err := o.db.ExecContext(ctx, query, time.Now().UTC(), id.String())
+	return err
+}
+
+// MarkFailed implements the Outbox interface
+func (o SQLOutbox) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, retryAt time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET attempts = %s, retry_at = %s WHERE id = %s", o.table, o.ph(1), o.ph(2), o.ph(3))
+	_, err := o.db.ExecContext(ctx, query, attempts, retryAt, id.String())
+	return err
+}
@@ -0,0 +1,103 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/outbox"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLOutbox(t *testing.T) {
+	ev := userAdded{ID: uuid.New(), UserName: "Bond"}
+	payload, _ := json.Marshal(ev)
+	registry := events.NewRegistry(events.JSONEncoder{})
+	registry.Register(ev)
+
+	t.Run(`Given a SQL outbox, when events are stored, then they are inserted within the transaction`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO events_outbox (id, aggregate_id, name, payload, created_at) VALUES ($1, $2, $3, $4, $5)`)).
+			WithArgs(sqlmock.AnyArg(), ev.ID.String(), ev.Name(), payload, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO events_outbox`).
+			WithArgs(sqlmock.AnyArg(), ev.ID.String(), ev.Name(), payload, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		o := outbox.NewSQLOutbox(db, registry, outbox.WithTable("events_outbox"), outbox.WithPlaceholder(sqlx.Dollar))
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		require.NoError(t, o.Store(context.Background(), tx, ev, ev))
+		require.NoError(t, tx.Commit())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL outbox, when the unsent events are requested, then they are decoded in order`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		var (
+			id      = uuid.New()
+			unknown = uuid.New()
+			now     = time.Now().UTC()
+		)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, payload, created_at, attempts FROM outbox WHERE sent_at IS NULL AND (retry_at IS NULL OR retry_at <= ?) ORDER BY seq LIMIT ?`)).
+			WithArgs(now, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "payload", "created_at", "attempts"}).
+				AddRow(unknown.String(), "unknown", payload, now, 1).
+				AddRow(id.String(), ev.Name(), payload, now, 0))
+
+		records, err := outbox.NewSQLOutbox(db, registry).Unsent(context.Background(), now, 10)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, unknown, records[0].ID)
+		require.Equal(t, 1, records[0].Attempts)
+		require.ErrorIs(t, records[0].Err, events.ErrUnknownEvent)
+		require.Equal(t, outbox.Record{ID: id, Event: ev, CreatedAt: now}, records[1])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL outbox, when an event fails to be relayed, then its attempts and retry time are updated`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		var (
+			id      = uuid.New()
+			retryAt = time.Now().UTC()
+		)
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE outbox SET attempts = ?, retry_at = ? WHERE id = ?`)).
+			WithArgs(2, retryAt, id.String()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, outbox.NewSQLOutbox(db, registry).MarkFailed(context.Background(), id, 2, retryAt))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL outbox, when an event is marked as sent, then its row is updated`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		id := uuid.New()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE outbox SET sent_at = ? WHERE id = ?`)).
+			WithArgs(sqlmock.AnyArg(), id.String()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, outbox.NewSQLOutbox(db, registry).MarkSent(context.Background(), id))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,40 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+)
+
+// ErrNotEvent is returned when a dispatchable that is not an event is dispatched to a projection
+var ErrNotEvent = errors.New("not an event")
+
+// Handler applies an event to a read model
+type Handler func(ctx context.Context, e events.Event) error
+
+// Projection builds a read model from events
+type Projection interface {
+	// Name identifies the projection, and its checkpoint
+	Name() string
+	// Handlers returns the handler of each event name the projection is interested in.
+	// The events with other names are skipped.
+	Handlers() map[string]Handler
+	// Reset clears the read model, so it can be rebuilt from scratch
+	Reset(ctx context.Context) error
+}
+
+// Status is the progress of a projection
+type Status struct {
+	Name string
+	// Position is the position of the last processed event
+	Position int64
+	// Head is the position of the last known event
+	Head int64
+	// Lag is the number of events not processed yet
+	Lag         int64
+	LastError   error
+	LastErrorAt time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,247 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+	"github.com/theskyinflames/cqrs-eda/pkg/subscription"
+)
+
+// RunnerOpt is an option for the runner constructor
+type RunnerOpt func(*Runner)
+
+// WithCheckpoints makes the runner save the position of the projection when it's fed from the event store,
+// so it resumes from it. Without checkpoints, the projection is fed from the beginning on each Run.
+func WithCheckpoints(c subscription.Checkpoints) RunnerOpt {
+	return func(r *Runner) {
+		r.checkpoints = c
+	}
+}
+
+// WithBatchSize sets the number of events read at once from the event store. By default, it's 100.
+func WithBatchSize(n int) RunnerOpt {
+	return func(r *Runner) {
+		r.batchSize = n
+	}
+}
+
+type runnerState struct {
+	// handling serializes the events applied to the projection, so mux is not held while the handlers run
+	handling  sync.Mutex
+	mux       sync.Mutex
+	position  int64
+	head      int64
+	headFunc  func(ctx context.Context) (int64, error)
+	lastErr   error
+	lastErrAt time.Time
+	updatedAt time.Time
+}
+
+// Runner feeds a projection with events and tracks its progress. The events are applied one at a time,
+// in the order they are received. A runner is meant to be fed from a single source: either
+// the event store, with Run, or live events from a listener or a bus.
+type Runner struct {
+	p           Projection
+	handlers    map[string]Handler
+	checkpoints subscription.Checkpoints
+	batchSize   int
+	state       *runnerState
+}
+
+// NewRunner is a constructor
+func NewRunner(p Projection, opts ...RunnerOpt) Runner {
+	r := Runner{
+		p:         p,
+		handlers:  p.Handlers(),
+		batchSize: 100,
+		state:     &runnerState{},
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// Handle applies a live event to the projection. Live events are numbered as they are received,
+// and the position is the number of the last one successfully applied.
+func (r Runner) Handle(ctx context.Context, e events.Event) error {
+	r.state.handling.Lock()
+	defer r.state.handling.Unlock()
+
+	r.state.mux.Lock()
+	r.state.head++
+	seq := r.state.head
+	r.state.mux.Unlock()
+
+	return r.apply(ctx, e, seq)
+}
+
+// ListenerHandler returns a handler to feed the projection from an events.Listener.
+// As listener handlers can't fail, the errors are only reported by Status.
+func (r Runner) ListenerHandler() events.Handler {
+	return func(e events.Event) {
+		_ = r.Handle(context.Background(), e)
+	}
+}
+
+// BusHandler returns a handler to feed the projection from a bus
+func (r Runner) BusHandler() bus.Handler {
+	return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+		e, ok := d.(events.Event)
+		if !ok {
+			return nil, ErrNotEvent
+		}
+		return nil, r.Handle(ctx, e)
+	}
+}
+
+// Register registers the bus handler for each event name the projection handles
+func (r Runner) Register(b bus.Registrar, mws ...bus.Middleware) {
+	for name := range r.handlers {
+		b.Register(name, r.BusHandler(), mws...)
+	}
+}
+
+// Run feeds the projection from the event store, catching up with the history, and then with the
+// new events as they are appended, until ctx is done or a handler fails. See subscription.Subscription.
+func (r Runner) Run(ctx context.Context, store eventstore.GlobalReader, opts ...subscription.Opt) error {
+	position, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	r.state.mux.Lock()
+	r.state.position = position
+	r.state.headFunc = store.Head
+	r.state.mux.Unlock()
+
+	opts = append([]subscription.Opt{subscription.WithBatchSize(r.batchSize)}, opts...)
+	if r.checkpoints != nil {
+		opts = append(opts, subscription.WithCheckpoints(r.checkpoints))
+	}
+	return subscription.New(r.p.Name(), store, r.handleRecord, opts...).Run(ctx)
+}
+
+// Rebuild resets the projection and feeds it with all the events of the event store, up to the
+// current head. It must not be called while the projection is being fed by Run.
+func (r Runner) Rebuild(ctx context.Context, store eventstore.GlobalReader) error {
+	r.state.mux.Lock()
+	r.state.position = 0
+	r.state.headFunc = store.Head
+	r.state.lastErr = nil
+	r.state.lastErrAt = time.Time{}
+	r.state.mux.Unlock()
+
+	if err := r.p.Reset(ctx); err != nil {
+		return fmt.Errorf("resetting projection %s: %w", r.p.Name(), err)
+	}
+	if err := r.save(ctx, 0); err != nil {
+		return err
+	}
+	var position int64
+	for {
+		records, err := store.ReadAll(ctx, position, r.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if err := r.handleRecord(ctx, rec); err != nil {
+				return err
+			}
+			position = rec.Position
+		}
+		if err := r.save(ctx, position); err != nil {
+			return err
+		}
+		if len(records) < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// Status returns the progress of the projection. When it's fed from the event store,
+// the head is read from it.
+func (r Runner) Status(ctx context.Context) (Status, error) {
+	r.state.mux.Lock()
+	headFunc := r.state.headFunc
+	r.state.mux.Unlock()
+
+	var head int64
+	if headFunc != nil {
+		var err error
+		if head, err = headFunc(ctx); err != nil {
+			return Status{}, fmt.Errorf("reading head of projection %s: %w", r.p.Name(), err)
+		}
+	}
+
+	r.state.mux.Lock()
+	defer r.state.mux.Unlock()
+	if headFunc == nil {
+		head = r.state.head
+	}
+	lag := head - r.state.position
+	if lag < 0 {
+		lag = 0
+	}
+	return Status{
+		Name:        r.p.Name(),
+		Position:    r.state.position,
+		Head:        head,
+		Lag:         lag,
+		LastError:   r.state.lastErr,
+		LastErrorAt: r.state.lastErrAt,
+		UpdatedAt:   r.state.updatedAt,
+	}, nil
+}
+
+func (r Runner) handleRecord(ctx context.Context, rec eventstore.Record) error {
+	r.state.handling.Lock()
+	defer r.state.handling.Unlock()
+	return r.apply(ctx, rec.Event, rec.Position)
+}
+
+// apply calls the handler of the event, if any, and then moves the projection to the given position.
+// It must be called with the handling mutex locked, but not the state one, so the status can be read meanwhile.
+func (r Runner) apply(ctx context.Context, e events.Event, position int64) error {
+	var err error
+	if h, ok := r.handlers[e.Name()]; ok {
+		err = h(ctx, e)
+	}
+
+	r.state.mux.Lock()
+	defer r.state.mux.Unlock()
+	if err != nil {
+		err = fmt.Errorf("projection %s, event %s: %w", r.p.Name(), e.Name(), err)
+		r.state.lastErr = err
+		r.state.lastErrAt = time.Now()
+		return err
+	}
+	r.state.position = position
+	r.state.updatedAt = time.Now()
+	return nil
+}
+
+func (r Runner) load(ctx context.Context) (int64, error) {
+	if r.checkpoints == nil {
+		return 0, nil
+	}
+	position, err := r.checkpoints.Load(ctx, r.p.Name())
+	if err != nil {
+		return 0, fmt.Errorf("loading checkpoint of projection %s: %w", r.p.Name(), err)
+	}
+	return position, nil
+}
+
+func (r Runner) save(ctx context.Context, position int64) error {
+	if r.checkpoints == nil {
+		return nil
+	}
+	if err := r.checkpoints.Save(ctx, r.p.Name(), position); err != nil {
+		return fmt.Errorf("saving checkpoint of projection %s: %w", r.p.Name(), err)
+	}
+	return nil
+}
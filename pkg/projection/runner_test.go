@@ -0,0 +1,255 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+	"github.com/theskyinflames/cqrs-eda/pkg/projection"
+	"github.com/theskyinflames/cqrs-eda/pkg/subscription"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// counter is a projection that counts the users added and removed
+type counter struct {
+	mux     *sync.Mutex
+	users   map[uuid.UUID]bool
+	resets  int
+	failing error
+}
+
+func newCounter() *counter {
+	return &counter{mux: &sync.Mutex{}, users: make(map[uuid.UUID]bool)}
+}
+
+func (c *counter) Name() string { return "users" }
+
+func (c *counter) Handlers() map[string]projection.Handler {
+	return map[string]projection.Handler{
+		"user.added": func(_ context.Context, e events.Event) error {
+			c.mux.Lock()
+			defer c.mux.Unlock()
+			if c.failing != nil {
+				return c.failing
+			}
+			c.users[e.AggregateID()] = true
+			return nil
+		},
+		"user.removed": func(_ context.Context, e events.Event) error {
+			c.mux.Lock()
+			defer c.mux.Unlock()
+			delete(c.users, e.AggregateID())
+			return nil
+		},
+	}
+}
+
+func (c *counter) Reset(context.Context) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.users = make(map[uuid.UUID]bool)
+	c.resets++
+	return nil
+}
+
+func (c *counter) count() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.users)
+}
+
+// blocking is a projection whose handler waits until it's released
+type blocking struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (blocking) Name() string { return "blocking" }
+
+func (b blocking) Handlers() map[string]projection.Handler {
+	return map[string]projection.Handler{
+		"user.added": func(context.Context, events.Event) error {
+			close(b.started)
+			<-b.release
+			return nil
+		},
+	}
+}
+
+func (blocking) Reset(context.Context) error { return nil }
+
+type command struct{}
+
+func (command) Name() string { return "user.added" }
+
+func appendEvent(t *testing.T, store eventstore.MemoryStore, id uuid.UUID, name string) {
+	evs, err := store.Load(context.Background(), id, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(context.Background(), id, len(evs), events.NewEventBasic(id, name, nil)))
+}
+
+func TestRunnerLive(t *testing.T) {
+	t.Run(`Given a runner registered in a bus, when events are dispatched, then they are projected and the status reports the failures`, func(t *testing.T) {
+		var (
+			p         = newCounter()
+			r         = projection.NewRunner(p)
+			b         = bus.New()
+			randomErr = errors.New("")
+			ctx       = context.Background()
+		)
+		r.Register(b)
+		require.ElementsMatch(t, []string{"user.added", "user.removed"}, b.Handlers())
+
+		_, err := b.Dispatch(ctx, events.NewEventBasic(uuid.New(), "user.added", nil))
+		require.NoError(t, err)
+		_, err = b.Dispatch(ctx, events.NewEventBasic(uuid.New(), "user.added", nil))
+		require.NoError(t, err)
+		require.Equal(t, 2, p.count())
+
+		status, err := r.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "users", status.Name)
+		require.Equal(t, int64(2), status.Position)
+		require.Zero(t, status.Lag)
+		require.NoError(t, status.LastError)
+
+		p.failing = randomErr
+		_, err = b.Dispatch(ctx, events.NewEventBasic(uuid.New(), "user.added", nil))
+		require.ErrorIs(t, err, randomErr)
+
+		status, err = r.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), status.Position)
+		require.Equal(t, int64(3), status.Head)
+		require.Equal(t, int64(1), status.Lag)
+		require.ErrorIs(t, status.LastError, randomErr)
+		require.False(t, status.LastErrorAt.IsZero())
+	})
+
+	t.Run(`Given a runner fed by a listener, when an event is received, then it's projected`, func(t *testing.T) {
+		var (
+			p  = newCounter()
+			r  = projection.NewRunner(p)
+			ch = make(chan events.Event)
+		)
+		l := events.NewListener(ch, "user.added", r.ListenerHandler())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go l.Listen(ctx, make(chan error, 1))
+
+		ch <- events.NewEventBasic(uuid.New(), "user.added", nil)
+		require.Eventually(t, func() bool { return p.count() == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run(`Given a runner whose handler is blocked, when its status is requested, then it's returned without waiting for the handler`, func(t *testing.T) {
+		var (
+			p       = blocking{started: make(chan struct{}), release: make(chan struct{})}
+			r       = projection.NewRunner(p)
+			ctx     = context.Background()
+			handled = make(chan error, 1)
+		)
+		go func() { handled <- r.Handle(ctx, events.NewEventBasic(uuid.New(), "user.added", nil)) }()
+		<-p.started
+
+		statusCh := make(chan projection.Status, 1)
+		go func() {
+			status, err := r.Status(ctx)
+			require.NoError(t, err)
+			statusCh <- status
+		}()
+		select {
+		case status := <-statusCh:
+			require.Equal(t, int64(1), status.Head)
+			require.Zero(t, status.Position)
+		case <-time.After(time.Second):
+			t.Fatal("the status is blocked by the handler")
+		}
+
+		close(p.release)
+		require.NoError(t, <-handled)
+		status, err := r.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), status.Position)
+	})
+
+	t.Run(`Given a runner registered in a bus, when something that is not an event is dispatched, then it fails`, func(t *testing.T) {
+		r := projection.NewRunner(newCounter())
+		_, err := r.BusHandler()(context.Background(), command{})
+		require.ErrorIs(t, err, projection.ErrNotEvent)
+	})
+}
+
+func TestRunnerStore(t *testing.T) {
+	t.Run(`Given an event store with history, when the runner runs, then the projection catches up and follows the new events`, func(t *testing.T) {
+		var (
+			store       = eventstore.NewMemoryStore()
+			checkpoints = subscription.NewMemoryCheckpoints()
+			p           = newCounter()
+			r           = projection.NewRunner(p, projection.WithCheckpoints(checkpoints), projection.WithBatchSize(2))
+			alice, bob  = uuid.New(), uuid.New()
+		)
+		appendEvent(t, store, alice, "user.added")
+		appendEvent(t, store, bob, "user.added")
+		appendEvent(t, store, alice, "user.renamed")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- r.Run(ctx, store, subscription.WithPollInterval(time.Hour)) }()
+
+		require.Eventually(t, func() bool {
+			status, err := r.Status(ctx)
+			require.NoError(t, err)
+			return status.Position == 3
+		}, time.Second, time.Millisecond)
+		require.Equal(t, 2, p.count())
+
+		appendEvent(t, store, bob, "user.removed")
+		require.Eventually(t, func() bool { return p.count() == 1 }, time.Second, time.Millisecond)
+
+		status, err := r.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), status.Head)
+		require.Zero(t, status.Lag)
+
+		cancel()
+		require.NoError(t, <-done)
+		position, err := checkpoints.Load(context.Background(), "users")
+		require.NoError(t, err)
+		require.Equal(t, int64(4), position)
+	})
+
+	t.Run(`Given a projection, when it's rebuilt, then it's reset and fed with all the history`, func(t *testing.T) {
+		var (
+			store       = eventstore.NewMemoryStore()
+			checkpoints = subscription.NewMemoryCheckpoints()
+			p           = newCounter()
+			r           = projection.NewRunner(p, projection.WithCheckpoints(checkpoints), projection.WithBatchSize(2))
+			ctx         = context.Background()
+		)
+		for i := 0; i < 3; i++ {
+			appendEvent(t, store, uuid.New(), "user.added")
+		}
+		p.users[uuid.New()] = true
+		require.NoError(t, checkpoints.Save(ctx, "users", 2))
+
+		require.NoError(t, r.Rebuild(ctx, store))
+		require.Equal(t, 1, p.resets)
+		require.Equal(t, 3, p.count())
+
+		position, err := checkpoints.Load(ctx, "users")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), position)
+
+		status, err := r.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), status.Position)
+		require.Zero(t, status.Lag)
+	})
+}
@@ -0,0 +1,130 @@
+package reqreply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTimeout is returned when the reply to a request doesn't arrive in time
+	ErrTimeout = errors.New("request timeout")
+
+	// ErrRemote is wrapped by the errors of the replies that only carry the error message
+	ErrRemote = errors.New("remote error")
+)
+
+// Request wraps a dispatchable that expects a reply. It's dispatched with the name of its payload,
+// and a Responder dispatches the payload itself, so it's handled by the handler of the payload.
+type Request struct {
+	// ID correlates the request with its reply
+	ID uuid.UUID
+	// ReplyTo is the address the reply has to be sent to
+	ReplyTo string
+	Payload bus.Dispatchable
+}
+
+// Name implements the bus.Dispatchable interface
+func (r Request) Name() string {
+	return r.Payload.Name()
+}
+
+// Reply is the reply to a request. It's dispatched with the reply address as its name.
+type Reply struct {
+	// CorrelationID is the ID of the request
+	CorrelationID uuid.UUID
+	ReplyTo       string
+	Response      interface{}
+	// Error is the message of the error of the request handler, if it failed
+	Error string
+
+	err error
+}
+
+// NewReply is a constructor
+func NewReply(req Request, response interface{}, err error) Reply {
+	rep := Reply{
+		CorrelationID: req.ID,
+		ReplyTo:       req.ReplyTo,
+		Response:      response,
+		err:           err,
+	}
+	if err != nil {
+		rep.Error = err.Error()
+	}
+	return rep
+}
+
+// Name implements the bus.Dispatchable interface
+func (r Reply) Name() string {
+	return r.ReplyTo
+}
+
+// Err returns the error of the request handler. If the reply has crossed a transport that only keeps
+// the error message, the error wraps ErrRemote.
+func (r Reply) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.Error != "" {
+		return fmt.Errorf("%w: %s", ErrRemote, r.Error)
+	}
+	return nil
+}
+
+// Replier sends the replies to their reply address
+type Replier interface {
+	Reply(ctx context.Context, rep Reply) error
+}
+
+// ReplierFunc is a function that implements the Replier interface
+type ReplierFunc func(ctx context.Context, rep Reply) error
+
+// Reply calls the function
+func (rf ReplierFunc) Reply(ctx context.Context, rep Reply) error {
+	return rf(ctx, rep)
+}
+
+// BusReplier returns a Replier that dispatches the replies, so they reach the handler registered
+// with the reply address, like Requester.Register does
+func BusReplier(d bus.Dispatcher) ReplierFunc {
+	return func(ctx context.Context, rep Reply) error {
+		_, err := d.Dispatch(ctx, rep)
+		return err
+	}
+}
+
+// Responder answers the requests. It dispatches their payload, so the handlers receive the payload itself,
+// with its identity, and send the response, or the error, through the replier once the handler and all its
+// middlewares, like retries, have finished. Other dispatchables, and the requests without reply address,
+// are just dispatched.
+type Responder struct {
+	d bus.Dispatcher
+	r Replier
+}
+
+// NewResponder is a constructor. Use bus.Await to dispatch to a ConcurrentBus or a PartitionedBus.
+func NewResponder(d bus.Dispatcher, r Replier) Responder {
+	return Responder{d: d, r: r}
+}
+
+// Dispatch implements the bus.Dispatcher interface. The error of the handler is sent with the reply,
+// so it's only returned when the request has no reply address.
+func (rs Responder) Dispatch(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+	req, ok := d.(Request)
+	if !ok {
+		return rs.d.Dispatch(ctx, d)
+	}
+	response, err := rs.d.Dispatch(ctx, req.Payload)
+	if req.ReplyTo == "" {
+		return response, err
+	}
+	if replyErr := rs.r.Reply(ctx, NewReply(req, response, err)); replyErr != nil {
+		return response, fmt.Errorf("replying to request %s: %w", req.ID, replyErr)
+	}
+	return response, nil
+}
@@ -0,0 +1,144 @@
+package reqreply_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/reqreply"
+	"github.com/theskyinflames/cqrs-eda/pkg/retry"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type getUser struct {
+	ID string
+}
+
+func (getUser) Name() string { return "get.user" }
+
+func TestRequester(t *testing.T) {
+	t.Run(`Given a responder in front of a bus, when a request is dispatched, then its reply is awaited`, func(t *testing.T) {
+		var (
+			b         = bus.New()
+			randomErr = errors.New("")
+			r         = reqreply.NewRequester(reqreply.NewResponder(b, reqreply.BusReplier(b)), "users.replies")
+			ctx       = context.Background()
+		)
+		r.Register(b)
+		b.Register("get.user", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			if d.(getUser).ID == "" {
+				return nil, randomErr
+			}
+			return "user " + d.(getUser).ID, nil
+		})
+
+		rs, err := r.Request(ctx, getUser{ID: "1"})
+		require.NoError(t, err)
+		require.Equal(t, "user 1", rs)
+
+		_, err = r.Request(ctx, getUser{})
+		require.ErrorIs(t, err, randomErr)
+
+		rs, err = b.Dispatch(ctx, getUser{ID: "2"})
+		require.NoError(t, err)
+		require.Equal(t, "user 2", rs)
+	})
+
+	t.Run(`Given a bus with a retry middleware, when a request fails and then succeeds, then only the successful reply is sent`, func(t *testing.T) {
+		var (
+			b        = bus.New()
+			replies  = bus.New()
+			replied  []reqreply.Reply
+			attempts int
+			replier  = reqreply.ReplierFunc(func(ctx context.Context, rep reqreply.Reply) error {
+				replied = append(replied, rep)
+				return reqreply.BusReplier(replies)(ctx, rep)
+			})
+			r = reqreply.NewRequester(reqreply.NewResponder(b, replier), "users.replies")
+		)
+		r.Register(replies)
+		b.Use(retry.BusMw(retry.NewPolicy(3, retry.WithBackoff(time.Millisecond, time.Millisecond, 1))))
+		b.Register("get.user", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			if attempts++; attempts == 1 {
+				return nil, errors.New("")
+			}
+			return "user " + d.(getUser).ID, nil
+		})
+
+		rs, err := r.Request(context.Background(), getUser{ID: "1"})
+		require.NoError(t, err)
+		require.Equal(t, "user 1", rs)
+		require.Len(t, replied, 1)
+	})
+
+	t.Run(`Given a ConcurrentBus, when a request is dispatched, then the reply comes back through the reply bus`, func(t *testing.T) {
+		var (
+			cb      = bus.NewConcurrentBus(time.Second, 2)
+			replies = bus.New()
+			r       = reqreply.NewRequester(reqreply.NewResponder(bus.Await(cb), reqreply.BusReplier(replies)), "users.replies")
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cb.Run(ctx)
+
+		r.Register(replies)
+		cb.Register("get.user", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			return "user " + d.(getUser).ID, nil
+		})
+
+		rs, err := r.RequestTimeout(ctx, getUser{ID: "1"}, time.Second)
+		require.NoError(t, err)
+		require.Equal(t, "user 1", rs)
+	})
+
+	t.Run(`Given a handler that doesn't reply, when a request is dispatched, then it times out`, func(t *testing.T) {
+		b := bus.New()
+		b.Register("get.user", func(context.Context, bus.Dispatchable) (interface{}, error) {
+			return nil, nil
+		})
+		r := reqreply.NewRequester(b, "users.replies", reqreply.WithTimeout(10*time.Millisecond))
+
+		_, err := r.Request(context.Background(), getUser{ID: "1"})
+		require.ErrorIs(t, err, reqreply.ErrTimeout)
+	})
+
+	t.Run(`Given a requester, when a reply arrives for a request that is not awaited, then it's ignored`, func(t *testing.T) {
+		r := reqreply.NewRequester(bus.New(), "users.replies")
+		_, err := r.Handle(context.Background(), reqreply.NewReply(reqreply.Request{ID: uuid.New(), ReplyTo: "users.replies"}, nil, nil))
+		require.NoError(t, err)
+	})
+}
+
+func TestResponder(t *testing.T) {
+	t.Run(`Given a responder, when a request without reply address is dispatched, then its payload is handled without replying`, func(t *testing.T) {
+		var (
+			b         = bus.New()
+			randomErr = errors.New("")
+			replied   bool
+			rs        = reqreply.NewResponder(b, reqreply.ReplierFunc(func(context.Context, reqreply.Reply) error {
+				replied = true
+				return nil
+			}))
+		)
+		b.Register("get.user", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			return nil, randomErr
+		})
+
+		_, err := rs.Dispatch(context.Background(), reqreply.Request{ID: uuid.New(), Payload: getUser{ID: "1"}})
+		require.ErrorIs(t, err, randomErr)
+		require.False(t, replied)
+	})
+}
+
+func TestReply(t *testing.T) {
+	t.Run(`Given a reply that only carries the error message, when its error is requested, then it wraps ErrRemote`, func(t *testing.T) {
+		rep := reqreply.Reply{CorrelationID: uuid.New(), ReplyTo: "users.replies", Error: "user not found"}
+		require.ErrorIs(t, rep.Err(), reqreply.ErrRemote)
+		require.Contains(t, rep.Err().Error(), "user not found")
+		require.Equal(t, "users.replies", rep.Name())
+	})
+}
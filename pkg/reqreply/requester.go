@@ -0,0 +1,118 @@
+package reqreply
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+
+	"github.com/google/uuid"
+)
+
+// RequesterOpt is an option for the requester constructor
+type RequesterOpt func(*Requester)
+
+// WithTimeout sets how long the requester awaits a reply by default. By default, it's 30 seconds.
+func WithTimeout(d time.Duration) RequesterOpt {
+	return func(r *Requester) {
+		r.timeout = d
+	}
+}
+
+// pending keeps the channels of the requests awaiting their reply
+type pending struct {
+	mux     sync.Mutex
+	replies map[uuid.UUID]chan Reply
+}
+
+// Requester dispatches requests and awaits their replies, correlated by the request ID.
+// The requests must reach a Responder, either because it's the requester dispatcher, or through a transport.
+// The replies must be delivered to its Handle method, for instance by registering it
+// with its reply address in the bus the replies are dispatched to.
+type Requester struct {
+	d       bus.Dispatcher
+	address string
+	timeout time.Duration
+	pending *pending
+}
+
+// NewRequester is a constructor. The address identifies the requester, so the replies can be sent to it.
+func NewRequester(d bus.Dispatcher, address string, opts ...RequesterOpt) Requester {
+	r := Requester{
+		d:       d,
+		address: address,
+		timeout: 30 * time.Second,
+		pending: &pending{replies: make(map[uuid.UUID]chan Reply)},
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// Address is a getter
+func (r Requester) Address() string {
+	return r.address
+}
+
+// Request dispatches the payload as a request, and awaits its reply for the default timeout.
+// It returns the response of the reply, or its error.
+func (r Requester) Request(ctx context.Context, payload bus.Dispatchable) (interface{}, error) {
+	return r.RequestTimeout(ctx, payload, r.timeout)
+}
+
+// RequestTimeout is like Request, with the given timeout. If the reply doesn't arrive in time, it returns ErrTimeout.
+func (r Requester) RequestTimeout(ctx context.Context, payload bus.Dispatchable, timeout time.Duration) (interface{}, error) {
+	req := Request{ID: uuid.New(), ReplyTo: r.address, Payload: payload}
+	ch := make(chan Reply, 1)
+	r.pending.mux.Lock()
+	r.pending.replies[req.ID] = ch
+	r.pending.mux.Unlock()
+	defer func() {
+		r.pending.mux.Lock()
+		delete(r.pending.replies, req.ID)
+		r.pending.mux.Unlock()
+	}()
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := r.d.Dispatch(reqCtx, req); err != nil && reqCtx.Err() == nil {
+		return nil, fmt.Errorf("dispatching request %s: %w", req.ID, err)
+	}
+
+	select {
+	case rep := <-ch:
+		return rep.Response, rep.Err()
+	case <-reqCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %s %s", ErrTimeout, payload.Name(), req.ID)
+	}
+}
+
+// Handle delivers a reply to the request awaiting it. It has the signature of a bus.Handler.
+// The replies to the requests that are not awaited, because they have timed out or have already been
+// replied, are ignored.
+func (r Requester) Handle(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+	rep, ok := d.(Reply)
+	if !ok {
+		return nil, bus.ErrNotDispatchable
+	}
+	r.pending.mux.Lock()
+	ch, ok := r.pending.replies[rep.CorrelationID]
+	delete(r.pending.replies, rep.CorrelationID)
+	r.pending.mux.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	ch <- rep
+	return nil, nil
+}
+
+// Register registers the requester handler in the bus with its reply address
+func (r Requester) Register(b bus.Registrar, mws ...bus.Middleware) {
+	b.Register(r.address, r.Handle, mws...)
+}
@@ -0,0 +1,181 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+)
+
+// Classifier tells whether an error is worth retrying
+type Classifier func(error) bool
+
+// Always is a classifier that retries any error
+func Always(error) bool {
+	return true
+}
+
+// On returns a classifier that retries the errors that match, using errors.Is, any of the given ones
+func On(targets ...error) Classifier {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnType returns a classifier that retries the errors that match, using errors.As, the type T
+func OnType[T error]() Classifier {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// Policy is a retry policy with exponential backoff
+type Policy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	multiplier     float64
+	jitter         float64
+	retryable      Classifier
+}
+
+// PolicyOpt is an option for the policy constructor
+type PolicyOpt func(*Policy)
+
+// WithBackoff sets the exponential backoff. The wait before the attempt n+1 is initial*multiplier^(n-1),
+// up to maxBackoff. By default, it's 100ms, 10s and 2.
+func WithBackoff(initial, maxBackoff time.Duration, multiplier float64) PolicyOpt {
+	return func(p *Policy) {
+		p.initialBackoff = initial
+		p.maxBackoff = maxBackoff
+		p.multiplier = multiplier
+	}
+}
+
+// WithJitter sets the fraction, between 0 and 1, of each backoff that is randomly removed
+// to spread the retries. By default, it's 0.2.
+func WithJitter(jitter float64) PolicyOpt {
+	return func(p *Policy) {
+		p.jitter = jitter
+	}
+}
+
+// WithRetryable sets the classifier of the retryable errors. By default, any error is retried.
+func WithRetryable(c Classifier) PolicyOpt {
+	return func(p *Policy) {
+		p.retryable = c
+	}
+}
+
+// NewPolicy is a constructor
+func NewPolicy(maxAttempts int, opts ...PolicyOpt) Policy {
+	p := Policy{
+		maxAttempts:    maxAttempts,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     10 * time.Second,
+		multiplier:     2,
+		jitter:         0.2,
+		retryable:      Always,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// Backoff returns the wait after the given failed attempt, starting by 1
+func (p Policy) Backoff(attempt int) time.Duration {
+	backoff := float64(p.initialBackoff) * math.Pow(p.multiplier, float64(attempt-1))
+	if backoff > float64(p.maxBackoff) {
+		backoff = float64(p.maxBackoff)
+	}
+	if p.jitter > 0 {
+		backoff -= backoff * p.jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// Error is returned when the retried function does not succeed
+type Error struct {
+	Attempts int
+	Err      error
+}
+
+// Error implements the error interface
+func (e Error) Error() string {
+	return fmt.Sprintf("after %d attempts: %s", e.Attempts, e.Err.Error())
+}
+
+// Unwrap returns the error of the last attempt
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Do calls f until it succeeds, its error is not retryable, the attempts are exhausted or ctx is done.
+// It doesn't wait for a retry that would start after the ctx deadline.
+// When f does not succeed, an Error with the last f error is returned.
+func Do(ctx context.Context, p Policy, f func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= p.maxAttempts || !p.retryable(err) || ctx.Err() != nil {
+			return Error{Attempts: attempt, Err: err}
+		}
+
+		backoff := p.Backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return Error{Attempts: attempt, Err: err}
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Error{Attempts: attempt, Err: err}
+		case <-timer.C:
+		}
+	}
+}
+
+// BusMw is a bus middleware that retries the wrapped handler following the policy
+func BusMw(p Policy) bus.Middleware {
+	return func(h bus.Handler) bus.Handler {
+		return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+			var rs interface{}
+			err := Do(ctx, p, func(ctx context.Context) error {
+				var err error
+				rs, err = h(ctx, d)
+				return err
+			})
+			return rs, err
+		}
+	}
+}
+
+// ChMw is a command handler middleware that retries the wrapped command handler following the policy
+func ChMw(p Policy) cqrs.CommandHandlerMiddleware {
+	return func(ch cqrs.CommandHandler) cqrs.CommandHandler {
+		return cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) ([]events.Event, error) {
+			var evs []events.Event
+			err := Do(ctx, p, func(ctx context.Context) error {
+				var err error
+				evs, err = ch.Handle(ctx, cmd)
+				return err
+			})
+			return evs, err
+		})
+	}
+}
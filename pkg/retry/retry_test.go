@@ -0,0 +1,176 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/retry"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string { return "timeout" }
+
+type dispatchable struct{}
+
+func (dispatchable) Name() string { return "d" }
+
+func failingFunc(calls *int, failures int, err error) func(context.Context) error {
+	return func(_ context.Context) error {
+		*calls++
+		if *calls <= failures {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	t.Run(`Given a policy without jitter, when the backoff is computed, then it grows exponentially up to the max`, func(t *testing.T) {
+		p := retry.NewPolicy(10, retry.WithBackoff(10*time.Millisecond, 50*time.Millisecond, 2), retry.WithJitter(0))
+		require.Equal(t, 10*time.Millisecond, p.Backoff(1))
+		require.Equal(t, 20*time.Millisecond, p.Backoff(2))
+		require.Equal(t, 40*time.Millisecond, p.Backoff(3))
+		require.Equal(t, 50*time.Millisecond, p.Backoff(4))
+	})
+
+	t.Run(`Given a policy with jitter, when the backoff is computed, then it's randomly reduced`, func(t *testing.T) {
+		p := retry.NewPolicy(10, retry.WithBackoff(100*time.Millisecond, time.Second, 2), retry.WithJitter(0.5))
+		for i := 0; i < 100; i++ {
+			b := p.Backoff(1)
+			require.LessOrEqual(t, b, 100*time.Millisecond)
+			require.GreaterOrEqual(t, b, 50*time.Millisecond)
+		}
+	})
+}
+
+func TestDo(t *testing.T) {
+	var (
+		randomErr = errors.New("")
+		fastOpts  = []retry.PolicyOpt{retry.WithBackoff(time.Millisecond, time.Millisecond, 1), retry.WithJitter(0)}
+	)
+
+	tests := []struct {
+		name             string
+		policy           retry.Policy
+		failures         int
+		err              error
+		expectedCalls    int
+		expectedAttempts int
+	}{
+		{
+			name:          `Given a function that fails less times than the max attempts, then it succeeds`,
+			policy:        retry.NewPolicy(3, fastOpts...),
+			failures:      2,
+			err:           randomErr,
+			expectedCalls: 3,
+		},
+		{
+			name:             `Given a function that always fails, then the attempts are exhausted`,
+			policy:           retry.NewPolicy(3, fastOpts...),
+			failures:         10,
+			err:              randomErr,
+			expectedCalls:    3,
+			expectedAttempts: 3,
+		},
+		{
+			name:             `Given a function that fails with a non retryable error, then it's not retried`,
+			policy:           retry.NewPolicy(3, append(fastOpts, retry.WithRetryable(retry.On(context.DeadlineExceeded)))...),
+			failures:         10,
+			err:              randomErr,
+			expectedCalls:    1,
+			expectedAttempts: 1,
+		},
+		{
+			name:          `Given a function that fails with an error retryable by type, then it's retried`,
+			policy:        retry.NewPolicy(3, append(fastOpts, retry.WithRetryable(retry.OnType[timeoutErr]()))...),
+			failures:      1,
+			err:           timeoutErr{},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		var calls int
+		err := retry.Do(context.Background(), tt.policy, failingFunc(&calls, tt.failures, tt.err))
+		require.Equal(t, tt.expectedCalls, calls, tt.name)
+		if tt.expectedAttempts == 0 {
+			require.NoError(t, err, tt.name)
+			continue
+		}
+		require.ErrorIs(t, err, tt.err, tt.name)
+		var retryErr retry.Error
+		require.ErrorAs(t, err, &retryErr, tt.name)
+		require.Equal(t, tt.expectedAttempts, retryErr.Attempts, tt.name)
+	}
+
+	t.Run(`Given a context with a deadline before the next retry, when it fails, then it's not retried`, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var calls int
+		err := retry.Do(ctx, retry.NewPolicy(3, retry.WithBackoff(time.Second, time.Second, 1)), failingFunc(&calls, 10, randomErr))
+		require.ErrorIs(t, err, randomErr)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run(`Given a context cancelled while waiting for the next retry, when it's cancelled, then it returns`, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		err := retry.Do(ctx, retry.NewPolicy(3, retry.WithBackoff(time.Hour, time.Hour, 1)), failingFunc(&calls, 10, randomErr))
+		require.ErrorIs(t, err, randomErr)
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestBusMw(t *testing.T) {
+	t.Run(`Given a bus handler wrapped by the retry middleware, when it fails once, then it's retried`, func(t *testing.T) {
+		var calls int
+		h := retry.BusMw(retry.NewPolicy(2, retry.WithBackoff(time.Millisecond, time.Millisecond, 1)))(
+			func(_ context.Context, _ bus.Dispatchable) (interface{}, error) {
+				calls++
+				if calls == 1 {
+					return nil, errors.New("")
+				}
+				return "a response", nil
+			})
+
+		rs, err := h(context.Background(), dispatchable{})
+		require.NoError(t, err)
+		require.Equal(t, "a response", rs)
+		require.Equal(t, 2, calls)
+	})
+}
+
+func TestChMw(t *testing.T) {
+	t.Run(`Given a command handler wrapped by the retry middleware, when it fails once, then it's retried`, func(t *testing.T) {
+		var (
+			calls int
+			ev    = events.NewEventBasic(uuid.New(), "ev", nil)
+		)
+		ch := retry.ChMw(retry.NewPolicy(2, retry.WithBackoff(time.Millisecond, time.Millisecond, 1)))(
+			cqrs.CommandHandlerFunc(func(_ context.Context, _ cqrs.Command) ([]events.Event, error) {
+				calls++
+				if calls == 1 {
+					return nil, errors.New("")
+				}
+				return []events.Event{ev}, nil
+			}))
+
+		evs, err := ch.Handle(context.Background(), dispatchable{})
+		require.NoError(t, err)
+		require.Equal(t, []events.Event{ev}, evs)
+		require.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,278 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// Handler reacts to an event of a saga instance. It can change the saga data, queue commands to be dispatched,
+// and complete or fail the saga.
+type Handler[D any] func(ctx context.Context, s *Instance[D], e events.Event) error
+
+// TimeoutHandler reacts to the timeout of a saga instance. Its deadline has been cleared, so unless the handler
+// sets a new one, completes or fails the saga, it won't time out again.
+type TimeoutHandler[D any] func(ctx context.Context, s *Instance[D]) error
+
+// Compensation returns the commands that undo what the saga has done, according to its data
+type Compensation[D any] func(ctx context.Context, data D) []cqrs.Command
+
+// Opt is an option for the manager constructor
+type Opt[D any] func(*Manager[D])
+
+// WithTimeout sets how long a saga instance can run since it started. When it times out, the handler is called,
+// or the saga fails if it's nil.
+func WithTimeout[D any](timeout time.Duration, h TimeoutHandler[D]) Opt[D] {
+	return func(m *Manager[D]) {
+		m.timeout = timeout
+		m.onTimeout = h
+	}
+}
+
+// WithCompensation sets the commands to dispatch when a saga fails
+func WithCompensation[D any](c Compensation[D]) Opt[D] {
+	return func(m *Manager[D]) {
+		m.compensate = c
+	}
+}
+
+// WithClock sets the function that returns the current time. By default, it's time.Now.
+func WithClock[D any](now func() time.Time) Opt[D] {
+	return func(m *Manager[D]) {
+		m.now = now
+	}
+}
+
+// Manager runs the instances of a saga, a long-running process that reacts to events by dispatching commands.
+// Each instance is keyed by the correlation ID of its events, and its data, of type D, is persisted encoded as JSON.
+// The commands are dispatched within a context that carries the same correlation ID, so the events they raise
+// (see cqrs.ChEventMw) get back to the same instance.
+//
+// The commands are dispatched before the state is saved, so if saving it fails, the event can be handled again,
+// and the commands will be dispatched at least once.
+type Manager[D any] struct {
+	name       string
+	store      Store
+	bus        bus.Dispatcher
+	starters   map[string]Handler[D]
+	handlers   map[string]Handler[D]
+	timeout    time.Duration
+	onTimeout  TimeoutHandler[D]
+	compensate Compensation[D]
+	now        func() time.Time
+}
+
+// NewManager is a constructor. The name identifies the saga states in the store.
+func NewManager[D any](name string, store Store, b bus.Dispatcher, opts ...Opt[D]) Manager[D] {
+	m := Manager[D]{
+		name:     name,
+		store:    store,
+		bus:      b,
+		starters: make(map[string]Handler[D]),
+		handlers: make(map[string]Handler[D]),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
+}
+
+// Name is a getter
+func (m Manager[D]) Name() string {
+	return m.name
+}
+
+// StartOn sets the handler of an event that starts a new saga instance.
+// If the instance already exists, the event is skipped.
+func (m Manager[D]) StartOn(name string, h Handler[D]) {
+	m.starters[name] = h
+}
+
+// On sets the handler of an event for the running saga instances.
+// If there is no running instance for the event, it's skipped.
+func (m Manager[D]) On(name string, h Handler[D]) {
+	m.handlers[name] = h
+}
+
+// Handle handles an event. The instance is keyed by its correlation ID, or by its ID if it has none,
+// like events.ContextFromEvent does.
+func (m Manager[D]) Handle(ctx context.Context, e events.Event) error {
+	ctx = events.ContextFromEvent(ctx, e)
+	id, _, _ := events.CorrelationFromContext(ctx)
+	if id == uuid.Nil {
+		return ErrNoCorrelationID
+	}
+
+	st, err := m.store.Load(ctx, m.name, id)
+	if errors.Is(err, ErrNotFound) {
+		h, ok := m.starters[e.Name()]
+		if !ok {
+			return nil
+		}
+		st = State{ID: id, Saga: m.name, Status: Running}
+		if m.timeout > 0 {
+			st.Deadline = m.now().Add(m.timeout)
+		}
+		return m.process(ctx, st, func(s *Instance[D]) error { return h(ctx, s, e) })
+	}
+	if err != nil {
+		return fmt.Errorf("loading saga %s %s: %w", m.name, id, err)
+	}
+
+	h, ok := m.handlers[e.Name()]
+	if !ok || st.Status != Running {
+		return nil
+	}
+	return m.process(ctx, st, func(s *Instance[D]) error { return h(ctx, s, e) })
+}
+
+// BusHandler returns a handler to feed the saga from a bus
+func (m Manager[D]) BusHandler() bus.Handler {
+	return func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+		e, ok := d.(events.Event)
+		if !ok {
+			return nil, bus.ErrNotDispatchable
+		}
+		return nil, m.Handle(ctx, e)
+	}
+}
+
+// Register registers the bus handler for each event name the saga handles
+func (m Manager[D]) Register(b bus.Registrar, mws ...bus.Middleware) {
+	names := make(map[string]bool)
+	for name := range m.starters {
+		names[name] = true
+	}
+	for name := range m.handlers {
+		names[name] = true
+	}
+	for name := range names {
+		b.Register(name, m.BusHandler(), mws...)
+	}
+}
+
+// HandleTimeouts handles up to limit instances that have timed out. It stops at the first one that fails,
+// and returns the number of handled ones.
+func (m Manager[D]) HandleTimeouts(ctx context.Context, limit int) (int, error) {
+	states, err := m.store.Expired(ctx, m.name, m.now(), limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, st := range states {
+		ctx := events.ContextWithCorrelation(ctx, st.ID, uuid.Nil)
+		err := m.process(ctx, st, func(s *Instance[D]) error {
+			s.Deadline = time.Time{}
+			if m.onTimeout == nil {
+				s.Fail()
+				return nil
+			}
+			return m.onTimeout(ctx, s)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(states), nil
+}
+
+// Run handles the timed out instances every interval, until ctx is done.
+// Errors are sent to errCh, if it's not nil.
+func (m Manager[D]) Run(ctx context.Context, interval time.Duration, errCh chan<- error) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		if _, err := m.HandleTimeouts(ctx, 100); err != nil && errCh != nil && ctx.Err() == nil {
+			select {
+			case errCh <- err:
+			case <-ctx.Done():
+				return
+			}
+		}
+		resetTimer(timer, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// process runs f on the instance, dispatches the queued commands, or the compensating ones if it fails,
+// and saves its state
+func (m Manager[D]) process(ctx context.Context, st State, f func(*Instance[D]) error) error {
+	s := &Instance[D]{ID: st.ID, Deadline: st.Deadline}
+	if len(st.Data) > 0 {
+		if err := json.Unmarshal(st.Data, &s.Data); err != nil {
+			return fmt.Errorf("decoding saga %s %s: %w", m.name, st.ID, err)
+		}
+	}
+	if err := f(s); err != nil {
+		return fmt.Errorf("saga %s %s: %w", m.name, st.ID, err)
+	}
+
+	var sagaErr error
+	for _, cmd := range s.commands {
+		if _, err := m.bus.Dispatch(ctx, cmd); err != nil {
+			sagaErr = fmt.Errorf("saga %s %s, dispatching command %s: %w", m.name, st.ID, cmd.Name(), err)
+			s.Fail()
+			break
+		}
+	}
+	switch {
+	case s.failed:
+		st.Status = Failed
+		if err := m.compensateAll(ctx, st.ID, s.Data); err != nil && sagaErr == nil {
+			sagaErr = err
+		}
+	case s.completed:
+		st.Status = Completed
+	}
+
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return fmt.Errorf("encoding saga %s %s: %w", m.name, st.ID, err)
+	}
+	st.Data = data
+	st.Deadline = s.Deadline
+	st.Version++
+	st.UpdatedAt = m.now()
+	if err := m.store.Save(ctx, st); err != nil {
+		return fmt.Errorf("saving saga %s %s: %w", m.name, st.ID, err)
+	}
+	return sagaErr
+}
+
+// compensateAll dispatches all the compensating commands, even if some of them fail.
+// It returns the first error.
+func (m Manager[D]) compensateAll(ctx context.Context, id uuid.UUID, data D) error {
+	if m.compensate == nil {
+		return nil
+	}
+	var firstErr error
+	for _, cmd := range m.compensate(ctx, data) {
+		if _, err := m.bus.Dispatch(ctx, cmd); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("saga %s %s, dispatching compensating command %s: %w", m.name, id, cmd.Name(), err)
+		}
+	}
+	return firstErr
+}
+
+// resetTimer stops the timer, draining its channel if it had fired, and resets it
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
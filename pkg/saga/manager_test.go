@@ -0,0 +1,221 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/saga"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type command struct {
+	name string
+}
+
+func (c command) Name() string { return c.name }
+
+// orderEvent is a domain event that embeds EventBasic by value
+type orderEvent struct {
+	events.EventBasic
+}
+
+// order is the data of the order saga
+type order struct {
+	Reserved bool
+	Charged  bool
+}
+
+// commandsBus records the dispatched commands, and fails the ones in failing
+type commandsBus struct {
+	b            bus.Bus
+	dispatched   *[]string
+	correlations *[]uuid.UUID
+}
+
+func newCommandsBus(failing map[string]error, names ...string) commandsBus {
+	cb := commandsBus{b: bus.New(), dispatched: &[]string{}, correlations: &[]uuid.UUID{}}
+	for _, n := range names {
+		cb.b.Register(n, func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+			*cb.dispatched = append(*cb.dispatched, d.Name())
+			correlationID, _, _ := events.CorrelationFromContext(ctx)
+			*cb.correlations = append(*cb.correlations, correlationID)
+			return nil, failing[d.Name()]
+		})
+	}
+	return cb
+}
+
+func newOrderSaga(store saga.Store, b cqrs.Bus, opts ...saga.Opt[order]) saga.Manager[order] {
+	opts = append(opts, saga.WithCompensation(func(_ context.Context, o order) []cqrs.Command {
+		var cmds []cqrs.Command
+		if o.Reserved {
+			cmds = append(cmds, command{name: "release.stock"})
+		}
+		return cmds
+	}))
+	m := saga.NewManager("order", store, b, opts...)
+	m.StartOn("order.placed", func(_ context.Context, s *saga.Instance[order], _ events.Event) error {
+		s.Dispatch(command{name: "reserve.stock"})
+		s.Data.Reserved = true
+		return nil
+	})
+	m.On("stock.reserved", func(_ context.Context, s *saga.Instance[order], _ events.Event) error {
+		s.Dispatch(command{name: "charge.payment"})
+		return nil
+	})
+	m.On("payment.charged", func(_ context.Context, s *saga.Instance[order], _ events.Event) error {
+		s.Data.Charged = true
+		s.Complete()
+		return nil
+	})
+	return m
+}
+
+func TestManager(t *testing.T) {
+	t.Run(`Given a saga, when its events are handled, then it dispatches the commands with the same correlation ID until it completes`, func(t *testing.T) {
+		var (
+			store  = saga.NewMemoryStore()
+			cb     = newCommandsBus(nil, "reserve.stock", "charge.payment")
+			m      = newOrderSaga(store, cb.b)
+			ctx    = context.Background()
+			placed = events.NewEventBasic(uuid.New(), "order.placed", nil)
+			id     = placed.EventID()
+		)
+
+		require.NoError(t, m.Handle(ctx, placed))
+		require.NoError(t, m.Handle(ctx, events.NewEventBasic(uuid.New(), "stock.reserved", nil, events.WithCorrelation(id, uuid.New()))))
+
+		st, err := store.Load(ctx, "order", id)
+		require.NoError(t, err)
+		require.Equal(t, saga.Running, st.Status)
+		require.Equal(t, 2, st.Version)
+		require.JSONEq(t, `{"Reserved":true,"Charged":false}`, string(st.Data))
+
+		require.NoError(t, m.Handle(ctx, events.NewEventBasic(uuid.New(), "payment.charged", nil, events.WithCorrelation(id, uuid.New()))))
+		require.NoError(t, m.Handle(ctx, events.NewEventBasic(uuid.New(), "stock.reserved", nil, events.WithCorrelation(id, uuid.New()))))
+
+		st, err = store.Load(ctx, "order", id)
+		require.NoError(t, err)
+		require.Equal(t, saga.Completed, st.Status)
+		require.Equal(t, []string{"reserve.stock", "charge.payment"}, *cb.dispatched)
+		require.Equal(t, []uuid.UUID{id, id}, *cb.correlations)
+	})
+
+	t.Run(`Given an event that doesn't start a saga, when there is no running saga for it, then it's skipped`, func(t *testing.T) {
+		var (
+			store = saga.NewMemoryStore()
+			cb    = newCommandsBus(nil, "charge.payment")
+			m     = newOrderSaga(store, cb.b)
+			ev    = events.NewEventBasic(uuid.New(), "stock.reserved", nil)
+		)
+		require.NoError(t, m.Handle(context.Background(), ev))
+		require.Empty(t, *cb.dispatched)
+		_, err := store.Load(context.Background(), "order", ev.EventID())
+		require.ErrorIs(t, err, saga.ErrNotFound)
+	})
+
+	t.Run(`Given a saga, when a command fails, then the compensating commands are dispatched and it fails`, func(t *testing.T) {
+		var (
+			store     = saga.NewMemoryStore()
+			randomErr = errors.New("")
+			cb        = newCommandsBus(map[string]error{"charge.payment": randomErr}, "reserve.stock", "charge.payment", "release.stock")
+			m         = newOrderSaga(store, cb.b)
+			ctx       = context.Background()
+			placed    = events.NewEventBasic(uuid.New(), "order.placed", nil)
+		)
+		require.NoError(t, m.Handle(ctx, placed))
+		err := m.Handle(ctx, events.NewEventBasic(uuid.New(), "stock.reserved", nil, events.WithCorrelation(placed.EventID(), uuid.New())))
+		require.ErrorIs(t, err, randomErr)
+
+		st, err := store.Load(ctx, "order", placed.EventID())
+		require.NoError(t, err)
+		require.Equal(t, saga.Failed, st.Status)
+		require.Equal(t, []string{"reserve.stock", "charge.payment", "release.stock"}, *cb.dispatched)
+	})
+
+	t.Run(`Given a saga with a timeout, when it times out, then the timeout handler is called or it fails`, func(t *testing.T) {
+		var (
+			store = saga.NewMemoryStore()
+			cb    = newCommandsBus(nil, "reserve.stock", "release.stock", "remind.customer")
+			now   = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock = func() time.Time { return now }
+			ctx   = context.Background()
+		)
+		reminded := newOrderSaga(store, cb.b, saga.WithClock[order](clock),
+			saga.WithTimeout(time.Minute, func(_ context.Context, s *saga.Instance[order]) error {
+				s.Dispatch(command{name: "remind.customer"})
+				s.Deadline = now.Add(time.Hour)
+				return nil
+			}))
+		require.NoError(t, reminded.Handle(ctx, events.NewEventBasic(uuid.New(), "order.placed", nil)))
+
+		n, err := reminded.HandleTimeouts(ctx, 10)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		now = now.Add(time.Minute)
+		n, err = reminded.HandleTimeouts(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, []string{"reserve.stock", "remind.customer"}, *cb.dispatched)
+
+		failing := newOrderSaga(saga.NewMemoryStore(), cb.b, saga.WithClock[order](clock), saga.WithTimeout[order](time.Minute, nil))
+		placed := events.NewEventBasic(uuid.New(), "order.placed", nil)
+		require.NoError(t, failing.Handle(ctx, placed))
+
+		now = now.Add(time.Minute)
+		n, err = failing.HandleTimeouts(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, []string{"reserve.stock", "remind.customer", "reserve.stock", "release.stock"}, *cb.dispatched)
+	})
+
+	t.Run(`Given a saga whose commands raise domain events that embed EventBasic, when they're handled, then they advance the same instance`, func(t *testing.T) {
+		var (
+			store    = saga.NewMemoryStore()
+			commands = bus.New()
+			raised   = bus.New()
+			queue    []events.Event
+			ctx      = context.Background()
+		)
+		// The raised events are queued and handled once the saga has saved its state, like an asynchronous bus does
+		for _, name := range []string{"stock.reserved", "payment.charged"} {
+			raised.Register(name, func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+				queue = append(queue, d.(events.Event))
+				return nil, nil
+			})
+		}
+		for name, raise := range map[string]string{"reserve.stock": "stock.reserved", "charge.payment": "payment.charged"} {
+			raise := raise
+			ch := cqrs.ChEventMw(raised)(cqrs.CommandHandlerFunc(func(context.Context, cqrs.Command) ([]events.Event, error) {
+				return []events.Event{orderEvent{EventBasic: events.NewEventBasic(uuid.New(), raise, nil)}}, nil
+			}))
+			commands.Register(name, func(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+				return ch.Handle(ctx, d.(cqrs.Command))
+			})
+		}
+
+		m := newOrderSaga(store, commands)
+		placed := events.NewEventBasic(uuid.New(), "order.placed", nil)
+		require.NoError(t, m.Handle(ctx, placed))
+		for len(queue) > 0 {
+			e := queue[0]
+			queue = queue[1:]
+			require.IsType(t, orderEvent{}, e)
+			require.Equal(t, placed.EventID(), e.(events.Enveloped).Metadata().CorrelationID)
+			require.NoError(t, m.Handle(ctx, e))
+		}
+
+		st, err := store.Load(ctx, "order", placed.EventID())
+		require.NoError(t, err)
+		require.Equal(t, saga.Completed, st.Status)
+		require.JSONEq(t, `{"Reserved":true,"Charged":true}`, string(st.Data))
+	})
+}
@@ -0,0 +1,72 @@
+package saga
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type stateKey struct {
+	saga string
+	id   uuid.UUID
+}
+
+// MemoryStore is an in-memory saga store
+type MemoryStore struct {
+	mux    *sync.RWMutex
+	states map[stateKey]State
+}
+
+// NewMemoryStore is a constructor
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{
+		mux:    &sync.RWMutex{},
+		states: make(map[stateKey]State),
+	}
+}
+
+// Load implements the Store interface
+func (s MemoryStore) Load(_ context.Context, saga string, id uuid.UUID) (State, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	st, ok := s.states[stateKey{saga: saga, id: id}]
+	if !ok {
+		return State{}, ErrNotFound
+	}
+	return st, nil
+}
+
+// Save implements the Store interface
+func (s MemoryStore) Save(_ context.Context, st State) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	key := stateKey{saga: st.Saga, id: st.ID}
+	if s.states[key].Version != st.Version-1 {
+		return ErrConcurrencyConflict
+	}
+	st.Data = append([]byte(nil), st.Data...)
+	s.states[key] = st
+	return nil
+}
+
+// Expired implements the Store interface
+func (s MemoryStore) Expired(_ context.Context, saga string, now time.Time, limit int) ([]State, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	var expired []State
+	for key, st := range s.states {
+		if key.saga == saga && st.Status == Running && !st.Deadline.IsZero() && !st.Deadline.After(now) {
+			expired = append(expired, st)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].Deadline.Before(expired[j].Deadline)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
@@ -0,0 +1,87 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/cqrs"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned when there is no state for a saga
+	ErrNotFound = errors.New("saga not found")
+
+	// ErrConcurrencyConflict is returned when a saga state has been changed since it was loaded
+	ErrConcurrencyConflict = errors.New("saga concurrency conflict")
+
+	// ErrNoCorrelationID is returned when an event has neither a correlation ID nor an ID to key the saga
+	ErrNoCorrelationID = errors.New("event without correlation ID")
+)
+
+// Status is the status of a saga
+type Status string
+
+const (
+	// Running is the status of a saga in progress
+	Running Status = "running"
+	// Completed is the status of a saga that has finished successfully
+	Completed Status = "completed"
+	// Failed is the status of a saga that has failed, and whose compensating commands have been dispatched
+	Failed Status = "failed"
+)
+
+// State is the persisted state of a saga instance
+type State struct {
+	// ID is the correlation ID of the events and commands of the saga instance
+	ID     uuid.UUID
+	Saga   string
+	Status Status
+	// Data is the encoded saga data
+	Data []byte
+	// Deadline is when the saga times out. It's zero if the saga has no timeout.
+	Deadline time.Time
+	// Version is incremented each time the state is saved
+	Version   int
+	UpdatedAt time.Time
+}
+
+// Store persists the saga states
+type Store interface {
+	// Load returns the state of a saga instance, or ErrNotFound
+	Load(ctx context.Context, saga string, id uuid.UUID) (State, error)
+	// Save stores the state, expecting the stored one to have the previous version, or none if it's the first one.
+	// Otherwise, it returns ErrConcurrencyConflict.
+	Save(ctx context.Context, s State) error
+	// Expired returns up to limit running instances of the saga whose deadline is not after now, the oldest first
+	Expired(ctx context.Context, saga string, now time.Time, limit int) ([]State, error)
+}
+
+// Instance is a saga instance, as seen by its handlers
+type Instance[D any] struct {
+	ID   uuid.UUID
+	Data D
+	// Deadline is when the saga times out. The handlers can extend it.
+	Deadline time.Time
+
+	commands  []cqrs.Command
+	completed bool
+	failed    bool
+}
+
+// Dispatch queues commands to be dispatched once the handler returns
+func (s *Instance[D]) Dispatch(cmds ...cqrs.Command) {
+	s.commands = append(s.commands, cmds...)
+}
+
+// Complete marks the saga as completed, so it won't handle more events
+func (s *Instance[D]) Complete() {
+	s.completed = true
+}
+
+// Fail marks the saga as failed, so its compensating commands are dispatched
+func (s *Instance[D]) Fail() {
+	s.failed = true
+}
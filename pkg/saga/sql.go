@@ -0,0 +1,156 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// SQLOpt is an option for the SQL saga store constructor
+type SQLOpt func(*SQLStore)
+
+// WithTable sets the sagas table name. By default, it's sagas.
+func WithTable(table string) SQLOpt {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLOpt {
+	return func(s *SQLStore) {
+		s.ph = ph
+	}
+}
+
+// SQLStore is a saga store on top of database/sql. It expects a table like this one:
+//
+//	CREATE TABLE sagas (
+//		saga       VARCHAR(255) NOT NULL,
+//		id         VARCHAR(36) NOT NULL,
+//		status     VARCHAR(16) NOT NULL,
+//		data       BLOB NOT NULL,
+//		deadline   TIMESTAMP NULL,
+//		version    INTEGER NOT NULL,
+//		updated_at TIMESTAMP NOT NULL,
+//		PRIMARY KEY (saga, id)
+//	)
+type SQLStore struct {
+	db    *sql.DB
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLStore is a constructor
+func NewSQLStore(db *sql.DB, opts ...SQLOpt) SQLStore {
+	s := SQLStore{
+		db:    db,
+		table: "sagas",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Load implements the Store interface
+func (s SQLStore) Load(ctx context.Context, saga string, id uuid.UUID) (State, error) {
+	query := fmt.Sprintf(
+		"SELECT status, data, deadline, version, updated_at FROM %s WHERE saga = %s AND id = %s",
+		s.table, s.ph(1), s.ph(2),
+	)
+	st, err := scanState(s.db.QueryRowContext(ctx, query, saga, id.String()), saga, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return State{}, ErrNotFound
+	}
+	return st, err
+}
+
+// Save implements the Store interface. The first version is inserted, and the next ones update the row
+// only if it has the previous version.
+func (s SQLStore) Save(ctx context.Context, st State) error {
+	deadline := sql.NullTime{Time: st.Deadline, Valid: !st.Deadline.IsZero()}
+	if st.Version == 1 {
+		query := fmt.Sprintf(
+			"INSERT INTO %s (saga, id, status, data, deadline, version, updated_at) VALUES (%s, %s, %s, %s, %s, %s, %s)",
+			s.table, s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5), s.ph(6), s.ph(7),
+		)
+		_, err := s.db.ExecContext(ctx, query, st.Saga, st.ID.String(), string(st.Status), st.Data, deadline, st.Version, st.UpdatedAt)
+		if err != nil {
+			if _, loadErr := s.Load(ctx, st.Saga, st.ID); loadErr == nil {
+				return ErrConcurrencyConflict
+			}
+		}
+		return err
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE %s SET status = %s, data = %s, deadline = %s, version = %s, updated_at = %s WHERE saga = %s AND id = %s AND version = %s",
+		s.table, s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5), s.ph(6), s.ph(7), s.ph(8),
+	)
+	rs, err := s.db.ExecContext(ctx, query, string(st.Status), st.Data, deadline, st.Version, st.UpdatedAt, st.Saga, st.ID.String(), st.Version-1)
+	if err != nil {
+		return err
+	}
+	n, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrConcurrencyConflict
+	}
+	return nil
+}
+
+// Expired implements the Store interface
+func (s SQLStore) Expired(ctx context.Context, saga string, now time.Time, limit int) ([]State, error) {
+	query := fmt.Sprintf(
+		"SELECT id, status, data, deadline, version, updated_at FROM %s WHERE saga = %s AND status = %s AND deadline <= %s ORDER BY deadline LIMIT %s",
+		s.table, s.ph(1), s.ph(2), s.ph(3), s.ph(4),
+	)
+	rows, err := s.db.QueryContext(ctx, query, saga, string(Running), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []State
+	for rows.Next() {
+		var (
+			id, status string
+			deadline   sql.NullTime
+			st         = State{Saga: saga}
+		)
+		if err := rows.Scan(&id, &status, &st.Data, &deadline, &st.Version, &st.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if st.ID, err = uuid.Parse(id); err != nil {
+			return nil, err
+		}
+		st.Status = Status(status)
+		st.Deadline = deadline.Time
+		states = append(states, st)
+	}
+	return states, rows.Err()
+}
+
+func scanState(row *sql.Row, saga string, id uuid.UUID) (State, error) {
+	var (
+		status   string
+		deadline sql.NullTime
+		st       = State{ID: id, Saga: saga}
+	)
+	if err := row.Scan(&status, &st.Data, &deadline, &st.Version, &st.UpdatedAt); err != nil {
+		return State{}, err
+	}
+	st.Status = Status(status)
+	st.Deadline = deadline.Time
+	return st, nil
+}
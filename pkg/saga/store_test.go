@@ -0,0 +1,124 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/saga"
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run(`Given a memory store, when states are saved, then they are versioned and the expired ones are returned`, func(t *testing.T) {
+		var (
+			s   = saga.NewMemoryStore()
+			ctx = context.Background()
+			now = time.Now()
+			st  = saga.State{ID: uuid.New(), Saga: "order", Status: saga.Running, Data: []byte("{}"), Deadline: now, Version: 1}
+		)
+		_, err := s.Load(ctx, "order", st.ID)
+		require.ErrorIs(t, err, saga.ErrNotFound)
+
+		require.NoError(t, s.Save(ctx, st))
+		require.ErrorIs(t, s.Save(ctx, st), saga.ErrConcurrencyConflict)
+
+		loaded, err := s.Load(ctx, "order", st.ID)
+		require.NoError(t, err)
+		require.Equal(t, st, loaded)
+
+		later := saga.State{ID: uuid.New(), Saga: "order", Status: saga.Running, Deadline: now.Add(-time.Minute), Version: 1}
+		noDeadline := saga.State{ID: uuid.New(), Saga: "order", Status: saga.Running, Version: 1}
+		other := saga.State{ID: uuid.New(), Saga: "shipping", Status: saga.Running, Deadline: now, Version: 1}
+		for _, st := range []saga.State{later, noDeadline, other} {
+			require.NoError(t, s.Save(ctx, st))
+		}
+
+		expired, err := s.Expired(ctx, "order", now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []saga.State{later, st}, expired)
+
+		st.Status, st.Version = saga.Completed, 2
+		require.NoError(t, s.Save(ctx, st))
+		expired, err = s.Expired(ctx, "order", now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []saga.State{later}, expired)
+	})
+}
+
+func TestSQLStore(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now().UTC()
+		st  = saga.State{ID: uuid.New(), Saga: "order", Status: saga.Running, Data: []byte("{}"), Deadline: now, Version: 1, UpdatedAt: now}
+	)
+
+	t.Run(`Given a SQL store, when the first version of a state is saved, then it's inserted`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO sagas (saga, id, status, data, deadline, version, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`)).
+			WithArgs("order", st.ID.String(), "running", st.Data, now, 1, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, saga.NewSQLStore(db).Save(ctx, st))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the first version of an existing state is saved, then it's a conflict`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO sagas`).WillReturnError(errors.New("duplicated key"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT status, data, deadline, version, updated_at FROM sagas WHERE saga = ? AND id = ?`)).
+			WithArgs("order", st.ID.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "data", "deadline", "version", "updated_at"}).
+				AddRow("running", st.Data, now, 1, now))
+
+		require.ErrorIs(t, saga.NewSQLStore(db).Save(ctx, st), saga.ErrConcurrencyConflict)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the next version of a state is saved, then it's updated only if it has the previous one`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		next := st
+		next.Status, next.Deadline, next.Version = saga.Completed, time.Time{}, 2
+		query := regexp.QuoteMeta(`UPDATE sagas SET status = $1, data = $2, deadline = $3, version = $4, updated_at = $5 WHERE saga = $6 AND id = $7 AND version = $8`)
+		mock.ExpectExec(query).
+			WithArgs("completed", st.Data, nil, 2, now, "order", st.ID.String(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		s := saga.NewSQLStore(db, saga.WithPlaceholder(sqlx.Dollar))
+		require.NoError(t, s.Save(ctx, next))
+		require.ErrorIs(t, s.Save(ctx, next), saga.ErrConcurrencyConflict)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the expired states are requested, then the running ones past their deadline are returned`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, status, data, deadline, version, updated_at FROM sagas WHERE saga = ? AND status = ? AND deadline <= ? ORDER BY deadline LIMIT ?`)).
+			WithArgs("order", "running", now, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "data", "deadline", "version", "updated_at"}).
+				AddRow(st.ID.String(), "running", st.Data, now, 1, now))
+
+		expired, err := saga.NewSQLStore(db).Expired(ctx, "order", now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []saga.State{st}, expired)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
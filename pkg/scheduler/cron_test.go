@@ -0,0 +1,47 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/scheduler"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron(t *testing.T) {
+	// It's a Sunday
+	from := time.Date(2023, 1, 1, 10, 30, 15, 0, time.UTC)
+
+	testCases := []struct {
+		expr string
+		next time.Time
+	}{
+		{expr: "* * * * *", next: time.Date(2023, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", next: time.Date(2023, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 2 * * *", next: time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{expr: "30 10 * * *", next: time.Date(2023, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 9-17/4 * * 1-5", next: time.Date(2023, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", next: time.Date(2023, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", next: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 15 * 3", next: time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{expr: "5,10 12 1,15 3 *", next: time.Date(2023, 3, 1, 12, 5, 0, 0, time.UTC)},
+		{expr: "@monthly", next: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", next: time.Date(2023, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{expr: "0 0 31 2 *", next: time.Time{}},
+	}
+	for _, tc := range testCases {
+		t.Run(`Given the cron expression `+tc.expr+`, when the next time is requested, then it's the first matching minute`, func(t *testing.T) {
+			c, err := scheduler.ParseCron(tc.expr)
+			require.NoError(t, err)
+			require.Equal(t, tc.next, c.Next(from))
+		})
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@never"} {
+		t.Run(`Given the invalid cron expression "`+expr+`", when it's parsed, then it fails`, func(t *testing.T) {
+			_, err := scheduler.ParseCron(expr)
+			require.ErrorIs(t, err, scheduler.ErrInvalidCron)
+		})
+	}
+}
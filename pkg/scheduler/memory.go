@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-memory schedule store. The scheduled items don't survive restarts.
+type MemoryStore struct {
+	mux     *sync.RWMutex
+	entries map[uuid.UUID]Entry
+}
+
+// NewMemoryStore is a constructor
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{
+		mux:     &sync.RWMutex{},
+		entries: make(map[uuid.UUID]Entry),
+	}
+}
+
+// Add implements the Store interface
+func (s MemoryStore) Add(_ context.Context, e Entry) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.entries[e.ID] = e
+	return nil
+}
+
+// Due implements the Store interface
+func (s MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]Entry, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	var due []Entry
+	for _, e := range s.entries {
+		if !e.DueAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DueAt.Before(due[j].DueAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Reschedule implements the Store interface
+func (s MemoryStore) Reschedule(_ context.Context, id uuid.UUID, dueAt time.Time, attempts int) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.DueAt = dueAt
+	e.Attempts = attempts
+	s.entries[id] = e
+	return nil
+}
+
+// Remove implements the Store interface
+func (s MemoryStore) Remove(_ context.Context, id uuid.UUID) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+// Len returns the number of scheduled items
+func (s MemoryStore) Len() int {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return len(s.entries)
+}
@@ -0,0 +1,311 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/deadletter"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when there is no scheduled item with the given ID
+var ErrNotFound = errors.New("scheduled item not found")
+
+// Codec encodes and decodes the scheduled dispatchables to be persisted. codec.Registry implements it.
+type Codec interface {
+	Encode(d bus.Dispatchable) ([]byte, error)
+	Decode(name string, data []byte) (bus.Dispatchable, error)
+}
+
+// Entry is a scheduled dispatchable, as it's persisted
+type Entry struct {
+	ID      uuid.UUID
+	Name    string
+	Payload []byte
+	// DueAt is when the dispatchable has to be dispatched next
+	DueAt time.Time
+	// Cron is the expression of the recurring schedules. It's empty for the ones dispatched once.
+	Cron      string
+	CreatedAt time.Time
+	// Attempts is the number of times it has failed to be dispatched since it was due
+	Attempts int
+}
+
+// Store persists the scheduled entries
+type Store interface {
+	// Add stores a new entry
+	Add(ctx context.Context, e Entry) error
+	// Due returns up to limit entries that are due at now, the earliest first
+	Due(ctx context.Context, now time.Time, limit int) ([]Entry, error)
+	// Reschedule sets when the entry has to be dispatched next, and its failed attempts
+	Reschedule(ctx context.Context, id uuid.UUID, dueAt time.Time, attempts int) error
+	// Remove deletes the entry, or returns ErrNotFound
+	Remove(ctx context.Context, id uuid.UUID) error
+}
+
+// Opt is an option for the scheduler constructor
+type Opt func(*Scheduler)
+
+// WithClock sets the function that returns the current time. By default, it's time.Now.
+func WithClock(now func() time.Time) Opt {
+	return func(s *Scheduler) {
+		s.now = now
+	}
+}
+
+// WithInterval sets how often the scheduler checks for due entries. By default, it's 1 second.
+func WithInterval(d time.Duration) Opt {
+	return func(s *Scheduler) {
+		s.interval = d
+	}
+}
+
+// WithBatchSize sets the number of due entries read at once. By default, it's 100.
+func WithBatchSize(n int) Opt {
+	return func(s *Scheduler) {
+		s.batchSize = n
+	}
+}
+
+// WithBackoff sets how long a failed item waits to be dispatched again. The wait doubles on each failed attempt,
+// from initial up to maxBackoff. By default, it's 1 second and 1 hour.
+func WithBackoff(initial, maxBackoff time.Duration) Opt {
+	return func(s *Scheduler) {
+		s.initialBackoff = initial
+		s.maxBackoff = maxBackoff
+	}
+}
+
+// WithDeadLetters sets the store where the items that have failed to be dispatched maxAttempts times are moved to.
+// By default, the failed items are retried forever.
+func WithDeadLetters(store deadletter.Store, maxAttempts int) Opt {
+	return func(s *Scheduler) {
+		s.deadLetters = store
+		s.maxAttempts = maxAttempts
+	}
+}
+
+// Scheduler dispatches commands and events at a given time, or recurrently following a cron expression.
+// The scheduled items are persisted, so they survive restarts. An item is removed, or rescheduled if it's recurring,
+// only after it has been dispatched, so it's dispatched at least once. Only one scheduler must run for each store.
+type Scheduler struct {
+	store     Store
+	codec     Codec
+	d         bus.Dispatcher
+	now       func() time.Time
+	interval  time.Duration
+	batchSize int
+
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	deadLetters    deadletter.Store
+	maxAttempts    int
+}
+
+// New is a constructor
+func New(store Store, codec Codec, d bus.Dispatcher, opts ...Opt) Scheduler {
+	s := Scheduler{
+		store:     store,
+		codec:     codec,
+		d:         d,
+		now:       time.Now,
+		interval:  time.Second,
+		batchSize: 100,
+
+		initialBackoff: time.Second,
+		maxBackoff:     time.Hour,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// DispatchAt schedules the dispatchable to be dispatched at the given time.
+// It returns the ID of the scheduled item, to cancel it.
+func (s Scheduler) DispatchAt(ctx context.Context, at time.Time, d bus.Dispatchable) (uuid.UUID, error) {
+	return s.add(ctx, at, "", d)
+}
+
+// DispatchAfter schedules the dispatchable to be dispatched after the given delay
+func (s Scheduler) DispatchAfter(ctx context.Context, delay time.Duration, d bus.Dispatchable) (uuid.UUID, error) {
+	return s.add(ctx, s.now().Add(delay), "", d)
+}
+
+// DispatchEvery schedules the dispatchable to be dispatched recurrently, following the cron expression (see ParseCron).
+// The times are computed in the location of the scheduler clock.
+func (s Scheduler) DispatchEvery(ctx context.Context, cron string, d bus.Dispatchable) (uuid.UUID, error) {
+	c, err := ParseCron(cron)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	next := c.Next(s.now())
+	if next.IsZero() {
+		return uuid.Nil, fmt.Errorf("%w: %q never matches", ErrInvalidCron, cron)
+	}
+	return s.add(ctx, next, cron, d)
+}
+
+// Cancel removes a scheduled item, or returns ErrNotFound
+func (s Scheduler) Cancel(ctx context.Context, id uuid.UUID) error {
+	return s.store.Remove(ctx, id)
+}
+
+func (s Scheduler) add(ctx context.Context, at time.Time, cron string, d bus.Dispatchable) (uuid.UUID, error) {
+	payload, err := s.codec.Encode(d)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("encoding %s: %w", d.Name(), err)
+	}
+	e := Entry{
+		ID:        uuid.New(),
+		Name:      d.Name(),
+		Payload:   payload,
+		DueAt:     at,
+		Cron:      cron,
+		CreatedAt: s.now(),
+	}
+	return e.ID, s.store.Add(ctx, e)
+}
+
+// DispatchDue dispatches a batch of due items. The ones dispatched once are removed, and the recurring ones
+// are rescheduled to their next time after now, so the missed times are skipped. If an item fails to be
+// dispatched, it's rescheduled to be retried after a backoff (see WithBackoff), or moved to the dead letters
+// (see WithDeadLetters), so the items that keep failing don't fill the next batches. It returns the number
+// of dispatched items, and the first error.
+func (s Scheduler) DispatchDue(ctx context.Context) (int, error) {
+	now := s.now()
+	entries, err := s.store.Due(ctx, now, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		n        int
+		firstErr error
+	)
+	for _, e := range entries {
+		if err := s.dispatch(ctx, now, e); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		n++
+	}
+	return n, firstErr
+}
+
+func (s Scheduler) dispatch(ctx context.Context, now time.Time, e Entry) error {
+	d, err := s.codec.Decode(e.Name, e.Payload)
+	if err != nil {
+		return s.failed(ctx, now, e, nil, fmt.Errorf("decoding scheduled item %s: %w", e.ID, err))
+	}
+	if _, err := s.d.Dispatch(ctx, d); err != nil {
+		return s.failed(ctx, now, e, d, fmt.Errorf("dispatching scheduled item %s: %w", e.ID, err))
+	}
+	if e.Cron == "" {
+		return s.remove(ctx, e.ID)
+	}
+	c, err := ParseCron(e.Cron)
+	if err != nil {
+		return fmt.Errorf("scheduled item %s: %w", e.ID, err)
+	}
+	next := c.Next(now)
+	if next.IsZero() {
+		return s.remove(ctx, e.ID)
+	}
+	if err := s.store.Reschedule(ctx, e.ID, next, 0); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("rescheduling item %s: %w", e.ID, err)
+	}
+	return nil
+}
+
+// failed reschedules an item that has failed to be dispatched after its backoff, or moves it to the dead letters
+// once it has reached the max attempts. The items that can't be decoded can't be moved, so they're rescheduled.
+// It returns the dispatch error.
+func (s Scheduler) failed(ctx context.Context, now time.Time, e Entry, d bus.Dispatchable, err error) error {
+	attempts := e.Attempts + 1
+	if s.deadLetters != nil && d != nil && attempts >= s.maxAttempts {
+		dl := deadletter.DeadLetter{
+			ID:            uuid.New(),
+			Dispatchable:  d,
+			Handler:       "scheduler",
+			Err:           err.Error(),
+			Attempts:      attempts,
+			FirstFailedAt: now,
+			LastFailedAt:  now,
+		}
+		if saveErr := s.deadLetters.Save(ctx, dl); saveErr != nil {
+			return fmt.Errorf("%w (saving dead letter: %s)", err, saveErr.Error())
+		}
+		if removeErr := s.remove(ctx, e.ID); removeErr != nil {
+			return fmt.Errorf("%w (%s)", err, removeErr.Error())
+		}
+		return err
+	}
+	rescheduleErr := s.store.Reschedule(ctx, e.ID, now.Add(s.backoff(attempts)), attempts)
+	if rescheduleErr != nil && !errors.Is(rescheduleErr, ErrNotFound) {
+		return fmt.Errorf("%w (rescheduling item: %s)", err, rescheduleErr.Error())
+	}
+	return err
+}
+
+// backoff returns the wait before the attempt n+1
+func (s Scheduler) backoff(n int) time.Duration {
+	b := s.initialBackoff
+	for i := 1; i < n && b < s.maxBackoff; i++ {
+		b *= 2
+	}
+	if b > s.maxBackoff {
+		return s.maxBackoff
+	}
+	return b
+}
+
+func (s Scheduler) remove(ctx context.Context, id uuid.UUID) error {
+	// It may have been cancelled while it was being dispatched
+	if err := s.store.Remove(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("removing scheduled item %s: %w", id, err)
+	}
+	return nil
+}
+
+// Run dispatches the due items until ctx is done. When a batch is full, the next one is dispatched
+// without waiting. Errors are sent to errCh, if it's not nil.
+func (s Scheduler) Run(ctx context.Context, errCh chan<- error) {
+	timer := time.NewTimer(s.interval)
+	defer timer.Stop()
+	for {
+		n, err := s.DispatchDue(ctx)
+		if err != nil && errCh != nil && ctx.Err() == nil {
+			select {
+			case errCh <- err:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err == nil && n == s.batchSize {
+			continue
+		}
+		resetTimer(timer, s.interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// resetTimer stops the timer, draining its channel if it had fired, and resets it
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
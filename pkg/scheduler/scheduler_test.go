@@ -0,0 +1,274 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/codec"
+	"github.com/theskyinflames/cqrs-eda/pkg/deadletter"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type sendReminder struct {
+	To string
+}
+
+func (sendReminder) Name() string { return "send.reminder" }
+
+type closeDay struct{}
+
+func (*closeDay) Name() string { return "close.day" }
+
+// recorder is a dispatcher that records the dispatched items, and fails while err is set
+type recorder struct {
+	dispatched []bus.Dispatchable
+	err        error
+}
+
+func (r *recorder) Dispatch(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.dispatched = append(r.dispatched, d)
+	return nil, nil
+}
+
+// failing is a dispatcher that fails to dispatch the reminders to a recipient
+type failing struct {
+	to         string
+	err        error
+	dispatched []bus.Dispatchable
+}
+
+func (f *failing) Dispatch(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+	if r, ok := d.(sendReminder); ok && r.To == f.to {
+		return nil, f.err
+	}
+	f.dispatched = append(f.dispatched, d)
+	return nil, nil
+}
+
+func newScheduler(store scheduler.Store, d bus.Dispatcher, now *time.Time, opts ...scheduler.Opt) scheduler.Scheduler {
+	registry := codec.NewRegistry(events.JSONEncoder{})
+	registry.Register(sendReminder{}, &closeDay{})
+	opts = append([]scheduler.Opt{scheduler.WithClock(func() time.Time { return *now })}, opts...)
+	return scheduler.New(store, registry, d, opts...)
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run(`Given items scheduled once, when they are due, then they are dispatched and removed`, func(t *testing.T) {
+		var (
+			store = scheduler.NewMemoryStore()
+			d     = &recorder{}
+			now   = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			s     = newScheduler(store, d, &now)
+			ctx   = context.Background()
+		)
+		_, err := s.DispatchAfter(ctx, 30*time.Minute, sendReminder{To: "alice"})
+		require.NoError(t, err)
+		_, err = s.DispatchAt(ctx, now.Add(10*time.Minute), sendReminder{To: "bob"})
+		require.NoError(t, err)
+
+		n, err := s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		now = now.Add(30 * time.Minute)
+		n, err = s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, []bus.Dispatchable{sendReminder{To: "bob"}, sendReminder{To: "alice"}}, d.dispatched)
+		require.Zero(t, store.Len())
+	})
+
+	t.Run(`Given a recurring item, when it's due, then it's dispatched and rescheduled skipping the missed times`, func(t *testing.T) {
+		var (
+			store = scheduler.NewMemoryStore()
+			d     = &recorder{}
+			now   = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			s     = newScheduler(store, d, &now)
+			ctx   = context.Background()
+		)
+		_, err := s.DispatchEvery(ctx, "0 2 * * *", &closeDay{})
+		require.NoError(t, err)
+
+		now = time.Date(2023, 1, 4, 3, 0, 0, 0, time.UTC)
+		n, err := s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, []bus.Dispatchable{&closeDay{}}, d.dispatched)
+
+		due, err := store.Due(ctx, time.Date(2023, 1, 5, 2, 0, 0, 0, time.UTC), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, time.Date(2023, 1, 5, 2, 0, 0, 0, time.UTC), due[0].DueAt)
+	})
+
+	t.Run(`Given a scheduled item, when it's cancelled, then it's not dispatched`, func(t *testing.T) {
+		var (
+			store = scheduler.NewMemoryStore()
+			d     = &recorder{}
+			now   = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			s     = newScheduler(store, d, &now)
+			ctx   = context.Background()
+		)
+		id, err := s.DispatchAfter(ctx, time.Minute, sendReminder{To: "alice"})
+		require.NoError(t, err)
+		require.NoError(t, s.Cancel(ctx, id))
+		require.ErrorIs(t, s.Cancel(ctx, id), scheduler.ErrNotFound)
+
+		now = now.Add(time.Hour)
+		n, err := s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		require.Empty(t, d.dispatched)
+	})
+
+	t.Run(`Given a due item, when it fails to be dispatched, then it's retried after a backoff that doubles`, func(t *testing.T) {
+		var (
+			store     = scheduler.NewMemoryStore()
+			randomErr = errors.New("")
+			d         = &recorder{err: randomErr}
+			now       = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			s         = newScheduler(store, d, &now, scheduler.WithBackoff(time.Minute, time.Hour))
+			ctx       = context.Background()
+		)
+		_, err := s.DispatchAt(ctx, now, sendReminder{To: "alice"})
+		require.NoError(t, err)
+
+		_, err = s.DispatchDue(ctx)
+		require.ErrorIs(t, err, randomErr)
+		require.Equal(t, 1, store.Len())
+		due, err := store.Due(ctx, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, 1, due[0].Attempts)
+
+		now = now.Add(time.Minute)
+		_, err = s.DispatchDue(ctx)
+		require.ErrorIs(t, err, randomErr)
+		due, err = store.Due(ctx, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Empty(t, due)
+
+		d.err = nil
+		now = now.Add(2 * time.Minute)
+		n, err := s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Zero(t, store.Len())
+	})
+
+	t.Run(`Given an events.EventBasic event, when it's due, then it's dispatched with its name and metadata`, func(t *testing.T) {
+		var (
+			store    = scheduler.NewMemoryStore()
+			d        = &recorder{}
+			now      = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			registry = codec.NewRegistry(events.JSONEncoder{})
+			ctx      = context.Background()
+		)
+		registry.Register(events.NewEventBasic(uuid.Nil, "day.closed", sendReminder{}))
+		s := scheduler.New(store, registry, d, scheduler.WithClock(func() time.Time { return now }))
+
+		ev := events.NewEventBasic(uuid.New(), "day.closed", sendReminder{To: "alice"}, events.WithCorrelation(uuid.New(), uuid.New()))
+		_, err := s.DispatchAt(ctx, now, ev)
+		require.NoError(t, err)
+
+		n, err := s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, []bus.Dispatchable{ev}, d.dispatched)
+	})
+
+	t.Run(`Given failing items that fill a batch, when the due items are dispatched again, then the next ones are dispatched`, func(t *testing.T) {
+		var (
+			store = scheduler.NewMemoryStore()
+			d     = &failing{to: "bob", err: errors.New("")}
+			now   = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			s     = newScheduler(store, d, &now, scheduler.WithBatchSize(2))
+			ctx   = context.Background()
+		)
+		for i, to := range []string{"bob", "bob", "alice"} {
+			_, err := s.DispatchAt(ctx, now.Add(time.Duration(i)*time.Second), sendReminder{To: to})
+			require.NoError(t, err)
+		}
+		now = now.Add(2 * time.Second)
+
+		n, err := s.DispatchDue(ctx)
+		require.ErrorIs(t, err, d.err)
+		require.Zero(t, n)
+
+		n, err = s.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, []bus.Dispatchable{sendReminder{To: "alice"}}, d.dispatched)
+		require.Equal(t, 2, store.Len())
+	})
+
+	t.Run(`Given a dead letter store, when an item fails to be dispatched the max attempts, then it's moved to it`, func(t *testing.T) {
+		var (
+			store       = scheduler.NewMemoryStore()
+			deadLetters = deadletter.NewMemoryStore()
+			randomErr   = errors.New("")
+			d           = &recorder{err: randomErr}
+			now         = time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+			s           = newScheduler(store, d, &now, scheduler.WithBackoff(time.Minute, time.Minute), scheduler.WithDeadLetters(deadLetters, 2))
+			ctx         = context.Background()
+		)
+		_, err := s.DispatchAt(ctx, now, sendReminder{To: "alice"})
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err = s.DispatchDue(ctx)
+			require.ErrorIs(t, err, randomErr)
+			now = now.Add(time.Minute)
+		}
+		require.Zero(t, store.Len())
+
+		dls, err := deadLetters.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, dls, 1)
+		require.Equal(t, sendReminder{To: "alice"}, dls[0].Dispatchable)
+		require.Equal(t, 2, dls[0].Attempts)
+	})
+
+	t.Run(`Given an invalid cron expression, when it's scheduled, then it fails`, func(t *testing.T) {
+		now := time.Now()
+		_, err := newScheduler(scheduler.NewMemoryStore(), &recorder{}, &now).DispatchEvery(context.Background(), "0 0 31 2 *", &closeDay{})
+		require.ErrorIs(t, err, scheduler.ErrInvalidCron)
+	})
+
+	t.Run(`Given a scheduler running, when an item is due, then it's dispatched`, func(t *testing.T) {
+		var (
+			b        = bus.New()
+			received = make(chan bus.Dispatchable, 1)
+			registry = codec.NewRegistry(events.GobEncoder{})
+		)
+		registry.Register(sendReminder{})
+		b.Register("send.reminder", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			received <- d
+			return nil, nil
+		})
+		s := scheduler.New(scheduler.NewMemoryStore(), registry, b, scheduler.WithInterval(time.Millisecond))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := s.DispatchAfter(ctx, 10*time.Millisecond, sendReminder{To: "alice"})
+		require.NoError(t, err)
+		go s.Run(ctx, nil)
+
+		select {
+		case d := <-received:
+			require.Equal(t, sendReminder{To: "alice"}, d)
+		case <-time.After(time.Second):
+			t.Fatal("the item has not been dispatched")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// SQLOpt is an option for the SQL schedule store constructor
+type SQLOpt func(*SQLStore)
+
+// WithTable sets the schedules table name. By default, it's schedules.
+func WithTable(table string) SQLOpt {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLOpt {
+	return func(s *SQLStore) {
+		s.ph = ph
+	}
+}
+
+// SQLStore is a schedule store on top of database/sql. It expects a table like this one:
+//
+//	CREATE TABLE schedules (
+//		id         VARCHAR(36) PRIMARY KEY,
+//		name       VARCHAR(255) NOT NULL,
+//		payload    BLOB NOT NULL,
+//		due_at     TIMESTAMP NOT NULL,
+//		cron       VARCHAR(255) NOT NULL,
+//		created_at TIMESTAMP NOT NULL,
+//		attempts   INT NOT NULL DEFAULT 0
+//	)
+type SQLStore struct {
+	db    *sql.DB
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLStore is a constructor
+func NewSQLStore(db *sql.DB, opts ...SQLOpt) SQLStore {
+	s := SQLStore{
+		db:    db,
+		table: "schedules",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Add implements the Store interface
+func (s SQLStore) Add(ctx context.Context, e Entry) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (id, name, payload, due_at, cron, created_at, attempts) VALUES (%s, %s, %s, %s, %s, %s, %s)",
+		s.table, s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5), s.ph(6), s.ph(7),
+	)
+	_, err := s.db.ExecContext(ctx, query, e.ID.String(), e.Name, e.Payload, e.DueAt, e.Cron, e.CreatedAt, e.Attempts)
+	return err
+}
+
+// Due implements the Store interface
+func (s SQLStore) Due(ctx context.Context, now time.Time, limit int) ([]Entry, error) {
+	query := fmt.Sprintf(
+		"SELECT id, name, payload, due_at, cron, created_at, attempts FROM %s WHERE due_at <= %s ORDER BY due_at LIMIT %s",
+		s.table, s.ph(1), s.ph(2),
+	)
+	rows, err := s.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var (
+			id string
+			e  Entry
+		)
+		if err := rows.Scan(&id, &e.Name, &e.Payload, &e.DueAt, &e.Cron, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		if e.ID, err = uuid.Parse(id); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Reschedule implements the Store interface
+func (s SQLStore) Reschedule(ctx context.Context, id uuid.UUID, dueAt time.Time, attempts int) error {
+	query := fmt.Sprintf("UPDATE %s SET due_at = %s, attempts = %s WHERE id = %s", s.table, s.ph(1), s.ph(2), s.ph(3))
+	return s.exec(ctx, query, dueAt, attempts, id.String())
+}
+
+// Remove implements the Store interface
+func (s SQLStore) Remove(ctx context.Context, id uuid.UUID) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = %s", s.table, s.ph(1))
+	return s.exec(ctx, query, id.String())
+}
+
+// exec runs a statement that affects a single entry, and returns ErrNotFound if there is none
+func (s SQLStore) exec(ctx context.Context, query string, args ...interface{}) error {
+	rs, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package scheduler_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/scheduler"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLStore(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now().UTC()
+		e   = scheduler.Entry{ID: uuid.New(), Name: "close.day", Payload: []byte("{}"), DueAt: now, Cron: "0 2 * * *", CreatedAt: now, Attempts: 1}
+	)
+
+	t.Run(`Given a SQL store, when an entry is added, then it's inserted`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schedules (id, name, payload, due_at, cron, created_at, attempts) VALUES (?, ?, ?, ?, ?, ?, ?)`)).
+			WithArgs(e.ID.String(), e.Name, e.Payload, e.DueAt, e.Cron, e.CreatedAt, e.Attempts).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, scheduler.NewSQLStore(db).Add(ctx, e))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the due entries are requested, then the earliest ones are returned`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, payload, due_at, cron, created_at, attempts FROM schedules WHERE due_at <= ? ORDER BY due_at LIMIT ?`)).
+			WithArgs(now, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "payload", "due_at", "cron", "created_at", "attempts"}).
+				AddRow(e.ID.String(), e.Name, e.Payload, e.DueAt, e.Cron, e.CreatedAt, e.Attempts))
+
+		due, err := scheduler.NewSQLStore(db).Due(ctx, now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []scheduler.Entry{e}, due)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when an entry is rescheduled or removed, then it fails if there is none`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		next := now.Add(24 * time.Hour)
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE jobs SET due_at = ?, attempts = ? WHERE id = ?`)).
+			WithArgs(next, 2, e.ID.String()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM jobs WHERE id = ?`)).
+			WithArgs(e.ID.String()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM jobs WHERE id = ?`)).
+			WithArgs(e.ID.String()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		s := scheduler.NewSQLStore(db, scheduler.WithTable("jobs"))
+		require.NoError(t, s.Reschedule(ctx, e.ID, next, 2))
+		require.NoError(t, s.Remove(ctx, e.ID))
+		require.ErrorIs(t, s.Remove(ctx, e.ID), scheduler.ErrNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FileStore is a snapshot store that keeps the latest snapshot of each aggregate in a JSON file of a directory
+type FileStore struct {
+	mux *sync.RWMutex
+	dir string
+}
+
+// NewFileStore is a constructor. The directory is created if it doesn't exist.
+func NewFileStore(dir string) (FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return FileStore{}, err
+	}
+	return FileStore{
+		mux: &sync.RWMutex{},
+		dir: dir,
+	}, nil
+}
+
+func (s FileStore) path(id uuid.UUID) string {
+	return filepath.Join(s.dir, id.String()+".json")
+}
+
+// Save implements the Store interface
+func (s FileStore) Save(_ context.Context, sn Snapshot) error {
+	b, err := json.Marshal(sn)
+	if err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if latest, err := s.read(sn.AggregateID); err == nil && latest.Version > sn.Version {
+		return nil
+	}
+	// Write and rename, so a crash doesn't leave a partial snapshot
+	tmp := s.path(sn.AggregateID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(sn.AggregateID))
+}
+
+// Latest implements the Store interface
+func (s FileStore) Latest(_ context.Context, aggregateID uuid.UUID) (Snapshot, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.read(aggregateID)
+}
+
+func (s FileStore) read(aggregateID uuid.UUID) (Snapshot, error) {
+	b, err := os.ReadFile(s.path(aggregateID))
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, ErrNotFound
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var sn Snapshot
+	err = json.Unmarshal(b, &sn)
+	return sn, err
+}
@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-memory snapshot store. It only keeps the latest snapshot of each aggregate.
+type MemoryStore struct {
+	mux       *sync.RWMutex
+	snapshots map[uuid.UUID]Snapshot
+}
+
+// NewMemoryStore is a constructor
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{
+		mux:       &sync.RWMutex{},
+		snapshots: make(map[uuid.UUID]Snapshot),
+	}
+}
+
+// Save implements the Store interface
+func (s MemoryStore) Save(_ context.Context, sn Snapshot) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if latest, ok := s.snapshots[sn.AggregateID]; ok && latest.Version > sn.Version {
+		return nil
+	}
+	s.snapshots[sn.AggregateID] = sn
+	return nil
+}
+
+// Latest implements the Store interface
+func (s MemoryStore) Latest(_ context.Context, aggregateID uuid.UUID) (Snapshot, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	sn, ok := s.snapshots[aggregateID]
+	if !ok {
+		return Snapshot{}, ErrNotFound
+	}
+	return sn, nil
+}
@@ -0,0 +1,61 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when an aggregate doesn't have any snapshot
+var ErrNotFound = errors.New("snapshot not found")
+
+// Snapshot is the state of an aggregate at a given version
+type Snapshot struct {
+	AggregateID uuid.UUID
+	// Version is the aggregate version, the number of events applied to the state
+	Version int
+	// SchemaVersion is the version of the state encoding. Snapshots with an outdated schema are ignored.
+	SchemaVersion int
+	State         []byte
+	CreatedAt     time.Time
+}
+
+// Store keeps the aggregate snapshots
+type Store interface {
+	// Save stores the snapshot
+	Save(ctx context.Context, s Snapshot) error
+	// Latest returns the snapshot of the aggregate with the highest version, or ErrNotFound
+	Latest(ctx context.Context, aggregateID uuid.UUID) (Snapshot, error)
+}
+
+// Policy decides whether to take a snapshot of an aggregate when it's saved, from its new version,
+// the number of events saved, and its latest snapshot, which is the zero Snapshot if there is none
+type Policy func(version, saved int, latest Snapshot) bool
+
+// EveryN takes a snapshot once n events have been saved since the latest one
+func EveryN(n int) Policy {
+	return func(version, _ int, latest Snapshot) bool {
+		return version-latest.Version >= n
+	}
+}
+
+// ByAge takes a snapshot when the latest one is older than maxAge, or when there is none
+func ByAge(maxAge time.Duration) Policy {
+	return func(_, _ int, latest Snapshot) bool {
+		return time.Since(latest.CreatedAt) >= maxAge
+	}
+}
+
+// Any takes a snapshot when any of the policies does
+func Any(policies ...Policy) Policy {
+	return func(version, saved int, latest Snapshot) bool {
+		for _, p := range policies {
+			if p(version, saved, latest) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,72 @@
+package snapshot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/snapshot"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	var (
+		recent = snapshot.Snapshot{Version: 10, CreatedAt: time.Now()}
+		old    = snapshot.Snapshot{Version: 10, CreatedAt: time.Now().Add(-time.Hour)}
+	)
+	tests := []struct {
+		name     string
+		policy   snapshot.Policy
+		version  int
+		latest   snapshot.Snapshot
+		expected bool
+	}{
+		{
+			name:     `Given an every N policy, when less than N events have been saved since the latest snapshot, then no snapshot is taken`,
+			policy:   snapshot.EveryN(5),
+			version:  14,
+			latest:   recent,
+			expected: false,
+		},
+		{
+			name:     `Given an every N policy, when N events have been saved since the latest snapshot, then a snapshot is taken`,
+			policy:   snapshot.EveryN(5),
+			version:  15,
+			latest:   recent,
+			expected: true,
+		},
+		{
+			name:     `Given a by age policy, when the latest snapshot is recent, then no snapshot is taken`,
+			policy:   snapshot.ByAge(time.Minute),
+			version:  11,
+			latest:   recent,
+			expected: false,
+		},
+		{
+			name:     `Given a by age policy, when the latest snapshot is old, then a snapshot is taken`,
+			policy:   snapshot.ByAge(time.Minute),
+			version:  11,
+			latest:   old,
+			expected: true,
+		},
+		{
+			name:     `Given a by age policy, when there is no snapshot, then a snapshot is taken`,
+			policy:   snapshot.ByAge(time.Minute),
+			version:  1,
+			expected: true,
+		},
+		{
+			name:     `Given an any policy, when one of its policies takes a snapshot, then a snapshot is taken`,
+			policy:   snapshot.Any(snapshot.EveryN(5), snapshot.ByAge(time.Minute)),
+			version:  11,
+			latest:   old,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.policy(tt.version, 1, tt.latest))
+		})
+	}
+}
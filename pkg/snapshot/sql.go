@@ -0,0 +1,85 @@
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+
+	"github.com/google/uuid"
+)
+
+// SQLOpt is an option for the SQL snapshot store constructor
+type SQLOpt func(*SQLStore)
+
+// WithTable sets the snapshots table name. By default, it's snapshots.
+func WithTable(table string) SQLOpt {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLOpt {
+	return func(s *SQLStore) {
+		s.ph = ph
+	}
+}
+
+// SQLStore is a snapshot store on top of database/sql. It expects a table like this one:
+//
+//	CREATE TABLE snapshots (
+//		aggregate_id   VARCHAR(36) NOT NULL,
+//		version        INTEGER NOT NULL,
+//		schema_version INTEGER NOT NULL,
+//		state          BLOB NOT NULL,
+//		created_at     TIMESTAMP NOT NULL,
+//		PRIMARY KEY (aggregate_id, version)
+//	)
+type SQLStore struct {
+	db    *sql.DB
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLStore is a constructor
+func NewSQLStore(db *sql.DB, opts ...SQLOpt) SQLStore {
+	s := SQLStore{
+		db:    db,
+		table: "snapshots",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Save implements the Store interface
+func (s SQLStore) Save(ctx context.Context, sn Snapshot) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (aggregate_id, version, schema_version, state, created_at) VALUES (%s, %s, %s, %s, %s)",
+		s.table, s.ph(1), s.ph(2), s.ph(3), s.ph(4), s.ph(5),
+	)
+	_, err := s.db.ExecContext(ctx, query, sn.AggregateID.String(), sn.Version, sn.SchemaVersion, sn.State, sn.CreatedAt)
+	return err
+}
+
+// Latest implements the Store interface
+func (s SQLStore) Latest(ctx context.Context, aggregateID uuid.UUID) (Snapshot, error) {
+	query := fmt.Sprintf(
+		"SELECT version, schema_version, state, created_at FROM %s WHERE aggregate_id = %s ORDER BY version DESC LIMIT 1",
+		s.table, s.ph(1),
+	)
+	sn := Snapshot{AggregateID: aggregateID}
+	err := s.db.QueryRowContext(ctx, query, aggregateID.String()).Scan(&sn.Version, &sn.SchemaVersion, &sn.State, &sn.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Snapshot{}, ErrNotFound
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return sn, nil
+}
@@ -0,0 +1,85 @@
+package snapshot_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/snapshot"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStores(t *testing.T) {
+	fileStore, err := snapshot.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	stores := map[string]snapshot.Store{
+		"memory": snapshot.NewMemoryStore(),
+		"file":   fileStore,
+	}
+	for name, s := range stores {
+		t.Run(`Given a `+name+` store, when snapshots are saved, then the latest one is returned`, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+				id  = uuid.New()
+				sn5 = snapshot.Snapshot{AggregateID: id, Version: 5, SchemaVersion: 1, State: []byte("5"), CreatedAt: time.Now().UTC()}
+				sn9 = snapshot.Snapshot{AggregateID: id, Version: 9, SchemaVersion: 1, State: []byte("9"), CreatedAt: time.Now().UTC()}
+			)
+
+			_, err := s.Latest(ctx, id)
+			require.ErrorIs(t, err, snapshot.ErrNotFound)
+
+			require.NoError(t, s.Save(ctx, sn9))
+			require.NoError(t, s.Save(ctx, sn5))
+
+			latest, err := s.Latest(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, sn9, latest)
+		})
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	var (
+		ctx = context.Background()
+		sn  = snapshot.Snapshot{AggregateID: uuid.New(), Version: 5, SchemaVersion: 2, State: []byte("state"), CreatedAt: time.Now().UTC()}
+	)
+
+	t.Run(`Given a SQL store, when a snapshot is saved, then it's inserted`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO snapshots (aggregate_id, version, schema_version, state, created_at) VALUES (?, ?, ?, ?, ?)`)).
+			WithArgs(sn.AggregateID.String(), sn.Version, sn.SchemaVersion, sn.State, sn.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, snapshot.NewSQLStore(db).Save(ctx, sn))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL store, when the latest snapshot is requested, then the one with the highest version is returned`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		query := regexp.QuoteMeta(`SELECT version, schema_version, state, created_at FROM snapshots WHERE aggregate_id = ? ORDER BY version DESC LIMIT 1`)
+		mock.ExpectQuery(query).WithArgs(sn.AggregateID.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "schema_version", "state", "created_at"}).
+				AddRow(sn.Version, sn.SchemaVersion, sn.State, sn.CreatedAt))
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"version", "schema_version", "state", "created_at"}))
+
+		s := snapshot.NewSQLStore(db)
+		latest, err := s.Latest(ctx, sn.AggregateID)
+		require.NoError(t, err)
+		require.Equal(t, sn, latest)
+
+		_, err = s.Latest(ctx, uuid.New())
+		require.ErrorIs(t, err, snapshot.ErrNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
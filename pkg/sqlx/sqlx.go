@@ -0,0 +1,34 @@
+// Package sqlx has the helpers shared by the stores on top of database/sql
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Placeholder returns the query placeholder for the nth argument, starting by 1
+type Placeholder func(n int) string
+
+// Question is the placeholder used by MySQL and SQLite
+func Question(int) string {
+	return "?"
+}
+
+// Dollar is the placeholder used by PostgreSQL
+func Dollar(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+type txCtxKey struct{}
+
+// WithTx returns a copy of ctx that carries the transaction
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txCtxKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txCtxKey{}).(*sql.Tx)
+	return tx, ok
+}
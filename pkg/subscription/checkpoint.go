@@ -0,0 +1,124 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/sqlx"
+)
+
+// Checkpoints keeps the position each subscription has reached, so it can resume from it
+type Checkpoints interface {
+	// Load returns the position of the subscription, or 0 if it has none
+	Load(ctx context.Context, subscription string) (int64, error)
+	// Save sets the position of the subscription
+	Save(ctx context.Context, subscription string, position int64) error
+}
+
+// MemoryCheckpoints is an in-memory checkpoints store
+type MemoryCheckpoints struct {
+	mux       *sync.RWMutex
+	positions map[string]int64
+}
+
+// NewMemoryCheckpoints is a constructor
+func NewMemoryCheckpoints() MemoryCheckpoints {
+	return MemoryCheckpoints{
+		mux:       &sync.RWMutex{},
+		positions: make(map[string]int64),
+	}
+}
+
+// Load implements the Checkpoints interface
+func (c MemoryCheckpoints) Load(_ context.Context, subscription string) (int64, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.positions[subscription], nil
+}
+
+// Save implements the Checkpoints interface
+func (c MemoryCheckpoints) Save(_ context.Context, subscription string, position int64) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.positions[subscription] = position
+	return nil
+}
+
+// SQLCheckpointsOpt is an option for the SQL checkpoints store constructor
+type SQLCheckpointsOpt func(*SQLCheckpoints)
+
+// WithTable sets the checkpoints table name. By default, it's checkpoints.
+func WithTable(table string) SQLCheckpointsOpt {
+	return func(c *SQLCheckpoints) {
+		c.table = table
+	}
+}
+
+// WithPlaceholder sets the query placeholder of the DB driver. By default, it's sqlx.Question.
+func WithPlaceholder(ph sqlx.Placeholder) SQLCheckpointsOpt {
+	return func(c *SQLCheckpoints) {
+		c.ph = ph
+	}
+}
+
+// SQLCheckpoints is a checkpoints store on top of database/sql. It expects a table like this one:
+//
+//	CREATE TABLE checkpoints (
+//		subscription VARCHAR(255) PRIMARY KEY,
+//		position     BIGINT NOT NULL
+//	)
+type SQLCheckpoints struct {
+	db    *sql.DB
+	table string
+	ph    sqlx.Placeholder
+}
+
+// NewSQLCheckpoints is a constructor
+func NewSQLCheckpoints(db *sql.DB, opts ...SQLCheckpointsOpt) SQLCheckpoints {
+	c := SQLCheckpoints{
+		db:    db,
+		table: "checkpoints",
+		ph:    sqlx.Question,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// Load implements the Checkpoints interface
+func (c SQLCheckpoints) Load(ctx context.Context, subscription string) (int64, error) {
+	var position int64
+	query := fmt.Sprintf("SELECT position FROM %s WHERE subscription = %s", c.table, c.ph(1))
+	err := c.db.QueryRowContext(ctx, query, subscription).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return position, err
+}
+
+// Save implements the Checkpoints interface. The position is saved within the transaction carried by ctx,
+// if any (see sqlx.WithTx), so it can be saved atomically with the subscriber's work.
+func (c SQLCheckpoints) Save(ctx context.Context, subscription string, position int64) error {
+	var exec interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	} = c.db
+	if tx, ok := sqlx.TxFromContext(ctx); ok {
+		exec = tx
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET position = %s WHERE subscription = %s", c.table, c.ph(1), c.ph(2))
+	rs, err := exec.ExecContext(ctx, query, position, subscription)
+	if err != nil {
+		return err
+	}
+	if n, err := rs.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	query = fmt.Sprintf("INSERT INTO %s (subscription, position) VALUES (%s, %s)", c.table, c.ph(1), c.ph(2))
+	_, err = exec.ExecContext(ctx, query, subscription, position)
+	return err
+}
@@ -0,0 +1,55 @@
+package subscription_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/subscription"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLCheckpoints(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		selectQuery = regexp.QuoteMeta(`SELECT position FROM checkpoints WHERE subscription = ?`)
+		updateQuery = regexp.QuoteMeta(`UPDATE checkpoints SET position = ? WHERE subscription = ?`)
+		insertQuery = regexp.QuoteMeta(`INSERT INTO checkpoints (subscription, position) VALUES (?, ?)`)
+	)
+
+	t.Run(`Given a SQL checkpoints store, when a checkpoint is loaded, then its position is returned, or 0 if there is none`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectQuery).WithArgs("projection").WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(7))
+		mock.ExpectQuery(selectQuery).WithArgs("another").WillReturnRows(sqlmock.NewRows([]string{"position"}))
+
+		c := subscription.NewSQLCheckpoints(db)
+		position, err := c.Load(ctx, "projection")
+		require.NoError(t, err)
+		require.Equal(t, int64(7), position)
+
+		position, err = c.Load(ctx, "another")
+		require.NoError(t, err)
+		require.Zero(t, position)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run(`Given a SQL checkpoints store, when a checkpoint is saved, then it's updated, or inserted if there is none`, func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(updateQuery).WithArgs(8, "projection").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(updateQuery).WithArgs(1, "another").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertQuery).WithArgs("another", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		c := subscription.NewSQLCheckpoints(db)
+		require.NoError(t, c.Save(ctx, "projection", 8))
+		require.NoError(t, c.Save(ctx, "another", 1))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
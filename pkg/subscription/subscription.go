@@ -0,0 +1,265 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+
+	"github.com/google/uuid"
+)
+
+// Handler handles the records of a subscription
+type Handler func(ctx context.Context, r eventstore.Record) error
+
+// Filter selects the records a subscription handles
+type Filter func(r eventstore.Record) bool
+
+// ByNames selects the records of the events with the given names
+func ByNames(names ...string) Filter {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return func(r eventstore.Record) bool {
+		return set[r.Event.Name()]
+	}
+}
+
+// ByAggregate selects the records of the given aggregate
+func ByAggregate(id uuid.UUID) Filter {
+	return func(r eventstore.Record) bool {
+		return r.AggregateID == id
+	}
+}
+
+// Opt is an option for the subscription constructor
+type Opt func(*Subscription)
+
+// WithFilter makes the subscription handle only the records selected by the filter
+func WithFilter(f Filter) Opt {
+	return func(s *Subscription) {
+		s.filter = f
+	}
+}
+
+// WithCheckpoints makes the subscription save its position after each batch, and resume from it.
+// Without checkpoints, the subscription starts from the beginning.
+func WithCheckpoints(c Checkpoints) Opt {
+	return func(s *Subscription) {
+		s.checkpoints = c
+	}
+}
+
+// WithBatchSize sets the number of records read at once. By default, it's 100.
+func WithBatchSize(n int) Opt {
+	return func(s *Subscription) {
+		s.batchSize = n
+	}
+}
+
+// WithPollInterval sets how often the store is polled for new events once the subscription is live.
+// By default, it's 1 second.
+func WithPollInterval(d time.Duration) Opt {
+	return func(s *Subscription) {
+		s.pollInterval = d
+	}
+}
+
+// WithGapTimeout sets how long the subscription waits for a gap in the positions to be filled before skipping it.
+// With a SQL store, concurrent transactions can commit their positions out of order, so a gap can be an event
+// that is not visible yet, or one whose transaction was rolled back. By default, it's 5 seconds.
+func WithGapTimeout(d time.Duration) Opt {
+	return func(s *Subscription) {
+		s.gapTimeout = d
+	}
+}
+
+// WithStartPosition sets the position the subscription starts after when it has no checkpoint,
+// like the Head of the store, to handle only the new events. By default, it's 0, the beginning.
+func WithStartPosition(p int64) Opt {
+	return func(s *Subscription) {
+		s.startPosition = p
+	}
+}
+
+// changeNotifier is an event store that notifies when events are appended, like eventstore.MemoryStore
+type changeNotifier interface {
+	Changed() <-chan struct{}
+}
+
+// Subscription reads the events of all the aggregates from a global position in the event store, catching up
+// with the history, and then keeps reading the new ones as they are appended. The records are delivered
+// at least once, because the position is saved after they have been handled. When there is a gap in the
+// positions, the subscription doesn't go past it until it's filled, or the gap timeout has passed.
+type Subscription struct {
+	name          string
+	store         eventstore.GlobalReader
+	h             Handler
+	filter        Filter
+	checkpoints   Checkpoints
+	batchSize     int
+	pollInterval  time.Duration
+	gapTimeout    time.Duration
+	startPosition int64
+}
+
+// cursor is the position a running subscription has reached, and the gap it's waiting for, if any
+type cursor struct {
+	position int64
+	// gap is the first missing position, or 0 if there is no gap
+	gap      int64
+	gapSince time.Time
+}
+
+// New is a constructor. The name identifies the subscription checkpoint.
+func New(name string, store eventstore.GlobalReader, h Handler, opts ...Opt) Subscription {
+	s := Subscription{
+		name:         name,
+		store:        store,
+		h:            h,
+		batchSize:    100,
+		pollInterval: time.Second,
+		gapTimeout:   5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Name is a getter
+func (s Subscription) Name() string {
+	return s.name
+}
+
+// Run handles the records until ctx is done, or the handler fails. In the last case, the position of
+// the last handled record is saved, and the handler error is returned, so the failed record will be
+// handled again when the subscription is resumed.
+func (s Subscription) Run(ctx context.Context) error {
+	position, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	c := &cursor{position: position}
+	timer := time.NewTimer(s.pollInterval)
+	defer timer.Stop()
+	for {
+		// Get the notification channel before reading, so no append is missed in between
+		var changed <-chan struct{}
+		if cn, ok := s.store.(changeNotifier); ok {
+			changed = cn.Changed()
+		}
+
+		n, err := s.catchUp(ctx, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if n == s.batchSize && c.gap == 0 {
+			continue
+		}
+
+		wait := s.pollInterval
+		if c.gap != 0 {
+			if untilSkip := s.gapTimeout - time.Since(c.gapSince); untilSkip < wait {
+				wait = untilSkip
+			}
+		}
+		resetTimer(timer, wait)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-timer.C:
+		}
+	}
+}
+
+// resetTimer stops the timer, draining its channel if it had fired, and resets it
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+func (s Subscription) load(ctx context.Context) (int64, error) {
+	if s.checkpoints == nil {
+		return s.startPosition, nil
+	}
+	position, err := s.checkpoints.Load(ctx, s.name)
+	if err != nil {
+		return 0, fmt.Errorf("loading checkpoint of subscription %s: %w", s.name, err)
+	}
+	if position == 0 {
+		return s.startPosition, nil
+	}
+	return position, nil
+}
+
+// catchUp handles a batch of records, and returns how many have been read. It stops at a gap in the positions,
+// until the gap is filled or the gap timeout has passed.
+func (s Subscription) catchUp(ctx context.Context, c *cursor) (int, error) {
+	position := &c.position
+	records, err := s.store.ReadAll(ctx, *position, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	from := *position
+	for _, r := range records {
+		if s.waitGap(c, r) {
+			break
+		}
+		if s.filter != nil && !s.filter(r) {
+			*position = r.Position
+			continue
+		}
+		if err := s.h(ctx, r); err != nil {
+			// The records of a split event share the position, so the last one can't be saved
+			if r.Position == *position {
+				*position = r.Position - 1
+			}
+			if saveErr := s.save(ctx, from, *position); saveErr != nil {
+				return 0, saveErr
+			}
+			return 0, fmt.Errorf("subscription %s, position %d: %w", s.name, r.Position, err)
+		}
+		*position = r.Position
+	}
+	return len(records), s.save(ctx, from, *position)
+}
+
+// waitGap tells whether the subscription has to wait before handling the record, because there is a gap
+// before it that may be filled yet
+func (s Subscription) waitGap(c *cursor, r eventstore.Record) bool {
+	if r.Position <= c.position+1 {
+		c.gap = 0
+		return false
+	}
+	if c.gap != c.position+1 {
+		c.gap = c.position + 1
+		c.gapSince = time.Now()
+	}
+	if time.Since(c.gapSince) < s.gapTimeout {
+		return true
+	}
+	c.gap = 0
+	return false
+}
+
+func (s Subscription) save(ctx context.Context, from, position int64) error {
+	if s.checkpoints == nil || position == from {
+		return nil
+	}
+	if err := s.checkpoints.Save(ctx, s.name, position); err != nil {
+		return fmt.Errorf("saving checkpoint of subscription %s: %w", s.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package subscription_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/eventstore"
+	"github.com/theskyinflames/cqrs-eda/pkg/subscription"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func appendEvents(t *testing.T, store eventstore.MemoryStore, id uuid.UUID, names ...string) {
+	evs, err := store.Load(context.Background(), id, 0)
+	require.NoError(t, err)
+	var newEvs []events.Event
+	for _, n := range names {
+		newEvs = append(newEvs, events.NewEventBasic(id, n, nil))
+	}
+	require.NoError(t, store.Append(context.Background(), id, len(evs), newEvs...))
+}
+
+// gappyStore is a global reader whose records are added by the test, so it can leave gaps in the positions
+type gappyStore struct {
+	mux     sync.Mutex
+	records []eventstore.Record
+}
+
+func (s *gappyStore) add(positions ...int64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, p := range positions {
+		s.records = append(s.records, eventstore.Record{Position: p, Event: events.NewEventBasic(uuid.New(), "created", nil)})
+	}
+	sort.Slice(s.records, func(i, j int) bool { return s.records[i].Position < s.records[j].Position })
+}
+
+func (s *gappyStore) ReadAll(_ context.Context, from int64, limit int) ([]eventstore.Record, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	var records []eventstore.Record
+	for _, r := range s.records {
+		if r.Position > from && (limit <= 0 || len(records) < limit) {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+func (s *gappyStore) Head(context.Context) (int64, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(s.records) == 0 {
+		return 0, nil
+	}
+	return s.records[len(s.records)-1].Position, nil
+}
+
+func TestSubscription(t *testing.T) {
+	t.Run(`Given an event store with history, when a subscription runs, then it catches up and then receives the live events`, func(t *testing.T) {
+		var (
+			store    = eventstore.NewMemoryStore()
+			id       = uuid.New()
+			received = make(chan eventstore.Record, 10)
+		)
+		appendEvents(t, store, id, "created", "renamed", "renamed")
+
+		s := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			received <- r
+			return nil
+		}, subscription.WithBatchSize(2), subscription.WithPollInterval(time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { require.NoError(t, s.Run(ctx)) }()
+
+		for i := int64(1); i <= 3; i++ {
+			require.Equal(t, i, (<-received).Position)
+		}
+
+		appendEvents(t, store, id, "deleted")
+		r := <-received
+		require.Equal(t, int64(4), r.Position)
+		require.Equal(t, 4, r.Version)
+		require.Equal(t, "deleted", r.Event.Name())
+	})
+
+	t.Run(`Given a subscription with checkpoints, when it's resumed, then it continues where it left off`, func(t *testing.T) {
+		var (
+			store       = eventstore.NewMemoryStore()
+			checkpoints = subscription.NewMemoryCheckpoints()
+			id          = uuid.New()
+			randomErr   = errors.New("")
+			handled     []int64
+		)
+		appendEvents(t, store, id, "created", "renamed", "deleted")
+
+		failing := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			if r.Position == 3 {
+				return randomErr
+			}
+			handled = append(handled, r.Position)
+			return nil
+		}, subscription.WithCheckpoints(checkpoints))
+		require.ErrorIs(t, failing.Run(context.Background()), randomErr)
+
+		position, err := checkpoints.Load(context.Background(), "projection")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), position)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		resumed := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			handled = append(handled, r.Position)
+			cancel()
+			return nil
+		}, subscription.WithCheckpoints(checkpoints))
+		require.NoError(t, resumed.Run(ctx))
+		require.Equal(t, []int64{1, 2, 3}, handled)
+	})
+
+	t.Run(`Given a subscription with a filter, when it runs, then it only receives the selected events`, func(t *testing.T) {
+		var (
+			store    = eventstore.NewMemoryStore()
+			id       = uuid.New()
+			received = make(chan eventstore.Record, 10)
+		)
+		appendEvents(t, store, id, "created", "renamed")
+		appendEvents(t, store, uuid.New(), "created")
+
+		s := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			received <- r
+			return nil
+		}, subscription.WithFilter(subscription.ByNames("created")), subscription.WithPollInterval(time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { require.NoError(t, s.Run(ctx)) }()
+
+		require.Equal(t, int64(1), (<-received).Position)
+		require.Equal(t, int64(3), (<-received).Position)
+
+		appendEvents(t, store, id, "deleted", "created")
+		require.Equal(t, int64(5), (<-received).Position)
+	})
+
+	t.Run(`Given a gap in the positions, when it's filled before the gap timeout, then the records are received in order`, func(t *testing.T) {
+		var (
+			store    = &gappyStore{}
+			received = make(chan int64, 10)
+		)
+		store.add(1, 3)
+		s := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			received <- r.Position
+			return nil
+		}, subscription.WithGapTimeout(time.Hour), subscription.WithPollInterval(time.Millisecond))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { require.NoError(t, s.Run(ctx)) }()
+
+		require.Equal(t, int64(1), <-received)
+		select {
+		case p := <-received:
+			t.Fatalf("unexpected position %d", p)
+		case <-time.After(50 * time.Millisecond):
+		}
+		store.add(2)
+		require.Equal(t, int64(2), <-received)
+		require.Equal(t, int64(3), <-received)
+	})
+
+	t.Run(`Given a gap in the positions, when it's not filled before the gap timeout, then it's skipped`, func(t *testing.T) {
+		var (
+			store    = &gappyStore{}
+			received = make(chan int64, 10)
+			timeout  = 50 * time.Millisecond
+		)
+		store.add(1, 3, 4)
+		s := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			received <- r.Position
+			return nil
+		}, subscription.WithGapTimeout(timeout), subscription.WithPollInterval(time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		start := time.Now()
+		go func() { require.NoError(t, s.Run(ctx)) }()
+
+		require.Equal(t, int64(1), <-received)
+		require.Equal(t, int64(3), <-received)
+		require.GreaterOrEqual(t, time.Since(start), timeout)
+		require.Equal(t, int64(4), <-received)
+	})
+
+	t.Run(`Given a start position, when a subscription without checkpoint runs, then it starts after it`, func(t *testing.T) {
+		var (
+			store       = eventstore.NewMemoryStore()
+			checkpoints = subscription.NewMemoryCheckpoints()
+			id          = uuid.New()
+			received    = make(chan int64, 10)
+		)
+		appendEvents(t, store, id, "created", "renamed", "deleted")
+		s := subscription.New("projection", store, func(_ context.Context, r eventstore.Record) error {
+			received <- r.Position
+			return nil
+		}, subscription.WithCheckpoints(checkpoints), subscription.WithStartPosition(2), subscription.WithPollInterval(time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { require.NoError(t, s.Run(ctx)) }()
+
+		require.Equal(t, int64(3), <-received)
+		require.Eventually(t, func() bool {
+			position, err := checkpoints.Load(ctx, "projection")
+			require.NoError(t, err)
+			return position == 3
+		}, time.Second, time.Millisecond)
+	})
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// Codec encodes and decodes the dispatchables to be carried by a transport.
+// codec.Registry implements it, carrying the events with their metadata.
+type Codec interface {
+	Encode(d bus.Dispatchable) ([]byte, error)
+	Decode(name string, data []byte) (bus.Dispatchable, error)
+}
+
+const (
+	// HeaderCorrelationID is the message header with the correlation ID of the events
+	HeaderCorrelationID = "correlation-id"
+	// HeaderCausationID is the message header with the causation ID of the events
+	HeaderCausationID = "causation-id"
+)
+
+// Publisher dispatches the dispatchables by publishing them to a transport, with their name as topic.
+// It has the Dispatch method of bus.Bus, so it can replace a bus to send commands or events to remote services,
+// or be used as the dispatcher of the outbox relay or the scheduler. The response is always nil.
+type Publisher struct {
+	t     Transport
+	codec Codec
+}
+
+// NewPublisher is a constructor
+func NewPublisher(t Transport, codec Codec) Publisher {
+	return Publisher{t: t, codec: codec}
+}
+
+// Dispatch publishes the dispatchable. If it's an event with metadata, its correlation and causation IDs
+// are sent as headers too, so the adapters can map them to the broker message properties.
+func (p Publisher) Dispatch(ctx context.Context, d bus.Dispatchable) (interface{}, error) {
+	payload, err := p.codec.Encode(d)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", d.Name(), err)
+	}
+	msg := Message{ID: uuid.New(), Topic: d.Name(), Payload: payload}
+	if ie, ok := d.(interface{ EventID() uuid.UUID }); ok && ie.EventID() != uuid.Nil {
+		msg.ID = ie.EventID()
+	}
+	if ee, ok := d.(events.Enveloped); ok && ee.Metadata().CorrelationID != uuid.Nil {
+		msg.Headers = map[string]string{
+			HeaderCorrelationID: ee.Metadata().CorrelationID.String(),
+			HeaderCausationID:   ee.Metadata().CausationID.String(),
+		}
+	}
+	return nil, p.t.Publish(ctx, msg)
+}
+
+// Consumer receives the dispatchables from a transport, and dispatches them to a local bus, so the bus
+// is backed by the transport. A message is acknowledged once it has been dispatched successfully,
+// and negatively acknowledged with requeue if it has failed, so it's delivered at least once.
+// The messages that can't be decoded are discarded. Use the retry and dead letter bus middlewares,
+// so a message that always fails is not redelivered forever.
+type Consumer struct {
+	t     Transport
+	codec Codec
+	d     bus.Dispatcher
+	group string
+}
+
+// NewConsumer is a constructor. The consumers with the same group compete for the messages.
+func NewConsumer(t Transport, codec Codec, d bus.Dispatcher, group string) Consumer {
+	return Consumer{t: t, codec: codec, d: d, group: group}
+}
+
+// Run subscribes to the topics, and dispatches their messages until ctx is done or the transport is closed.
+// The errors handling the messages are sent to errCh, if it's not nil.
+func (c Consumer) Run(ctx context.Context, errCh chan<- error, topics ...string) error {
+	deliveries := make([]<-chan Delivery, 0, len(topics))
+	for _, topic := range topics {
+		ch, err := c.t.Subscribe(ctx, topic, c.group)
+		if err != nil {
+			return fmt.Errorf("subscribing to %s: %w", topic, err)
+		}
+		deliveries = append(deliveries, ch)
+	}
+
+	done := make(chan struct{}, len(deliveries))
+	for _, ch := range deliveries {
+		go func(ch <-chan Delivery) {
+			for dl := range ch {
+				if err := c.handle(ctx, dl); err != nil && errCh != nil {
+					select {
+					case errCh <- err:
+					case <-ctx.Done():
+					}
+				}
+			}
+			done <- struct{}{}
+		}(ch)
+	}
+	for range deliveries {
+		<-done
+	}
+	return nil
+}
+
+func (c Consumer) handle(ctx context.Context, dl Delivery) error {
+	msg := dl.Message()
+	d, err := c.codec.Decode(msg.Topic, msg.Payload)
+	if err != nil {
+		if nackErr := dl.Nack(ctx, false); nackErr != nil {
+			log.Printf("nacking message %s: %s", msg.ID, nackErr)
+		}
+		return fmt.Errorf("decoding message %s: %w", msg.ID, err)
+	}
+	if _, err := c.d.Dispatch(ctx, d); err != nil {
+		if nackErr := dl.Nack(ctx, true); nackErr != nil {
+			log.Printf("nacking message %s: %s", msg.ID, nackErr)
+		}
+		return fmt.Errorf("dispatching message %s: %w", msg.ID, err)
+	}
+	return dl.Ack(ctx)
+}
@@ -0,0 +1,134 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/bus"
+	"github.com/theskyinflames/cqrs-eda/pkg/codec"
+	"github.com/theskyinflames/cqrs-eda/pkg/events"
+	"github.com/theskyinflames/cqrs-eda/pkg/transport"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type userAdded struct {
+	Name string
+}
+
+type addUser struct {
+	User string
+}
+
+func (addUser) Name() string { return "add.user" }
+
+func TestPublisherConsumer(t *testing.T) {
+	t.Run(`Given a consumer backing a bus, when an event is published, then it's dispatched to the bus with its metadata`, func(t *testing.T) {
+		var (
+			tr       = transport.NewMemoryTransport()
+			registry = codec.NewRegistry(events.JSONEncoder{})
+			b        = bus.New()
+			received = make(chan bus.Dispatchable, 1)
+		)
+		registry.Register(events.NewEventBasic(uuid.Nil, "user.added", userAdded{}))
+		b.Register("user.added", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			received <- d
+			return nil, nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error)
+		go func() { done <- transport.NewConsumer(tr, registry, b, "users").Run(ctx, nil, "user.added") }()
+
+		ev := events.NewEventBasic(uuid.New(), "user.added", userAdded{Name: "alice"}, events.WithCorrelation(uuid.New(), uuid.New()))
+		require.Eventually(t, func() bool {
+			_, err := transport.NewPublisher(tr, registry).Dispatch(ctx, ev)
+			require.NoError(t, err)
+			select {
+			case d := <-received:
+				require.Equal(t, ev, d)
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run(`Given a consumer, when the bus fails to handle a command, then it's redelivered`, func(t *testing.T) {
+		var (
+			tr        = transport.NewMemoryTransport()
+			registry  = codec.NewRegistry(events.JSONEncoder{})
+			b         = bus.New()
+			randomErr = errors.New("")
+			attempts  = make(chan addUser, 10)
+			errCh     = make(chan error, 1)
+			failed    bool
+		)
+		registry.Register(addUser{})
+		b.Register("add.user", func(_ context.Context, d bus.Dispatchable) (interface{}, error) {
+			attempts <- d.(addUser)
+			if !failed {
+				failed = true
+				return nil, randomErr
+			}
+			return nil, nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = transport.NewConsumer(tr, registry, b, "users").Run(ctx, errCh, "add.user") }()
+
+		require.Eventually(t, func() bool {
+			_, err := transport.NewPublisher(tr, registry).Dispatch(ctx, addUser{User: "alice"})
+			require.NoError(t, err)
+			select {
+			case err := <-errCh:
+				require.ErrorIs(t, err, randomErr)
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, time.Millisecond)
+
+		require.Eventually(t, func() bool { return len(attempts) >= 2 }, time.Second, time.Millisecond)
+		require.Equal(t, addUser{User: "alice"}, <-attempts)
+		require.Equal(t, addUser{User: "alice"}, <-attempts)
+	})
+
+	t.Run(`Given a consumer, when a message can't be decoded, then it's discarded`, func(t *testing.T) {
+		var (
+			tr    = transport.NewMemoryTransport()
+			errCh = make(chan error, 1)
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = transport.NewConsumer(tr, codec.NewRegistry(events.JSONEncoder{}), bus.New(), "users").Run(ctx, errCh, "add.user")
+		}()
+
+		require.Eventually(t, func() bool {
+			_, err := transport.NewPublisher(tr, codec.NewRegistry(events.JSONEncoder{})).Dispatch(ctx, addUser{User: "alice"})
+			require.NoError(t, err)
+			select {
+			case err := <-errCh:
+				require.ErrorIs(t, err, codec.ErrUnknownDispatchable)
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, time.Millisecond)
+
+		select {
+		case err := <-errCh:
+			t.Fatalf("unexpected redelivery: %s", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
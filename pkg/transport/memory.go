@@ -0,0 +1,216 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryOpt is an option for the memory transport constructor
+type MemoryOpt func(*MemoryTransport)
+
+// WithRedeliveryDelay sets how long a message negatively acknowledged with requeue waits to be delivered again.
+// By default, it's delivered again right away.
+func WithRedeliveryDelay(d time.Duration) MemoryOpt {
+	return func(t *MemoryTransport) {
+		t.redeliveryDelay = d
+	}
+}
+
+// MemoryTransport is the in-memory reference implementation of Transport. The messages published to a topic
+// are queued for each group subscribed to it, and the ones published before any group has subscribed are dropped.
+// The deliveries that are neither acknowledged nor negatively acknowledged when their subscription ends
+// are delivered again to their group.
+type MemoryTransport struct {
+	mux    *sync.Mutex
+	topics map[string]map[string]*memoryGroup
+	done   chan struct{}
+	closed *bool
+
+	redeliveryDelay time.Duration
+}
+
+type memoryGroup struct {
+	queue []Message
+	// changed is closed, and replaced, when messages are queued
+	changed chan struct{}
+}
+
+// memorySubscription keeps the deliveries of a subscriber that haven't been acknowledged yet
+type memorySubscription struct {
+	inFlight map[*memoryDelivery]struct{}
+}
+
+// NewMemoryTransport is a constructor
+func NewMemoryTransport(opts ...MemoryOpt) MemoryTransport {
+	t := MemoryTransport{
+		mux:    &sync.Mutex{},
+		topics: make(map[string]map[string]*memoryGroup),
+		done:   make(chan struct{}),
+		closed: new(bool),
+	}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	return t
+}
+
+// Publish implements the Transport interface
+func (t MemoryTransport) Publish(_ context.Context, msg Message) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if *t.closed {
+		return ErrClosed
+	}
+	for _, g := range t.topics[msg.Topic] {
+		t.push(g, copyMessage(msg))
+	}
+	return nil
+}
+
+// Subscribe implements the Transport interface
+func (t MemoryTransport) Subscribe(ctx context.Context, topic, group string) (<-chan Delivery, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if *t.closed {
+		return nil, ErrClosed
+	}
+	groups, ok := t.topics[topic]
+	if !ok {
+		groups = make(map[string]*memoryGroup)
+		t.topics[topic] = groups
+	}
+	g, ok := groups[group]
+	if !ok {
+		g = &memoryGroup{changed: make(chan struct{})}
+		groups[group] = g
+	}
+
+	ch := make(chan Delivery)
+	go t.deliver(ctx, g, ch)
+	return ch, nil
+}
+
+// Close implements the Transport interface
+func (t MemoryTransport) Close() error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if !*t.closed {
+		*t.closed = true
+		close(t.done)
+	}
+	return nil
+}
+
+// deliver sends the messages of the group to a subscriber, until ctx is done or the transport is closed.
+// Then, the deliveries that haven't been acknowledged are queued again.
+func (t MemoryTransport) deliver(ctx context.Context, g *memoryGroup, ch chan<- Delivery) {
+	sub := &memorySubscription{inFlight: make(map[*memoryDelivery]struct{})}
+	defer func() {
+		t.mux.Lock()
+		defer t.mux.Unlock()
+		for dl := range sub.inFlight {
+			t.push(g, dl.msg)
+		}
+		sub.inFlight = nil
+		close(ch)
+	}()
+	for {
+		t.mux.Lock()
+		if len(g.queue) == 0 {
+			changed := g.changed
+			t.mux.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.done:
+				return
+			case <-changed:
+			}
+			continue
+		}
+		dl := &memoryDelivery{t: t, g: g, sub: sub, msg: g.queue[0]}
+		g.queue = g.queue[1:]
+		sub.inFlight[dl] = struct{}{}
+		t.mux.Unlock()
+
+		select {
+		case ch <- dl:
+		case <-ctx.Done():
+			return
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// push queues a message for a group. It must be called with the transport locked.
+func (t MemoryTransport) push(g *memoryGroup, msg Message) {
+	g.queue = append(g.queue, msg)
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// settle removes a delivery from the in-flight ones of its subscription. It returns false if it was not
+// in flight, because it had already been settled or its subscription has ended.
+func (t MemoryTransport) settle(dl *memoryDelivery) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if _, ok := dl.sub.inFlight[dl]; !ok {
+		return false
+	}
+	delete(dl.sub.inFlight, dl)
+	return true
+}
+
+func (t MemoryTransport) requeue(g *memoryGroup, msg Message) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.push(g, msg)
+}
+
+func copyMessage(msg Message) Message {
+	msg.Payload = append([]byte(nil), msg.Payload...)
+	if msg.Headers != nil {
+		headers := make(map[string]string, len(msg.Headers))
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		msg.Headers = headers
+	}
+	return msg
+}
+
+type memoryDelivery struct {
+	t   MemoryTransport
+	g   *memoryGroup
+	sub *memorySubscription
+	msg Message
+}
+
+// Message implements the Delivery interface
+func (d *memoryDelivery) Message() Message {
+	return d.msg
+}
+
+// Ack implements the Delivery interface
+func (d *memoryDelivery) Ack(context.Context) error {
+	d.t.settle(d)
+	return nil
+}
+
+// Nack implements the Delivery interface. If it has already been queued again, because its subscription
+// has ended, it's not queued twice.
+func (d *memoryDelivery) Nack(_ context.Context, requeue bool) error {
+	if !d.t.settle(d) || !requeue {
+		return nil
+	}
+	if d.t.redeliveryDelay <= 0 {
+		d.t.requeue(d.g, d.msg)
+		return nil
+	}
+	time.AfterFunc(d.t.redeliveryDelay, func() {
+		d.t.requeue(d.g, d.msg)
+	})
+	return nil
+}
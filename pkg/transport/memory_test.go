@@ -0,0 +1,42 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/transport"
+	"github.com/theskyinflames/cqrs-eda/pkg/transport/transporttest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTransport(t *testing.T) {
+	transporttest.Run(t, func(*testing.T) transport.Transport {
+		return transport.NewMemoryTransport()
+	})
+
+	t.Run(`Given a redelivery delay, when a delivery is nacked with requeue, then it's delivered again after the delay`, func(t *testing.T) {
+		const delay = 50 * time.Millisecond
+		tr := transport.NewMemoryTransport(transport.WithRedeliveryDelay(delay))
+		defer tr.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := tr.Subscribe(ctx, "user.added", "group")
+		require.NoError(t, err)
+		msg := transport.Message{ID: uuid.New(), Topic: "user.added"}
+		require.NoError(t, tr.Publish(ctx, msg))
+
+		require.NoError(t, (<-ch).Nack(ctx, true))
+		nackedAt := time.Now()
+		select {
+		case dl := <-ch:
+			require.Equal(t, msg.ID, dl.Message().ID)
+			require.GreaterOrEqual(t, time.Since(nackedAt), delay)
+		case <-time.After(time.Second):
+			t.Fatal("the message has not been delivered again")
+		}
+	})
+}
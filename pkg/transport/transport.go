@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrClosed is returned when using a closed transport
+var ErrClosed = errors.New("transport closed")
+
+// Message is the unit of data carried by a transport
+type Message struct {
+	ID uuid.UUID
+	// Topic is where the message is published. The bus adapters use the dispatchable name.
+	Topic   string
+	Payload []byte
+	Headers map[string]string
+}
+
+// Delivery is a message received from a subscription. It must be acknowledged once it has been handled,
+// or negatively acknowledged if it could not.
+type Delivery interface {
+	Message() Message
+	// Ack confirms the message has been handled, so it won't be delivered again
+	Ack(ctx context.Context) error
+	// Nack rejects the message. If requeue is true, it will be delivered again.
+	// Otherwise, it's discarded, or sent to a dead letter destination if the transport has one.
+	Nack(ctx context.Context, requeue bool) error
+}
+
+// Transport publishes messages to topics, and delivers them to the subscribers of the topics.
+// Adapters for brokers must pass the conformance suite of the transporttest package, which checks this contract:
+//
+//   - A message is delivered to every group subscribed to its topic when it's published, and to only one
+//     subscriber of each group.
+//   - The ID, topic, payload and headers of the messages are preserved.
+//   - A message negatively acknowledged with requeue is delivered again to its group.
+//   - A message that is neither acknowledged nor negatively acknowledged when its subscription ends,
+//     because its context is done, is delivered again to its group.
+//   - The channel of a subscription is closed when its context is done, or the transport is closed.
+//   - Publishing to, or subscribing to, a closed transport returns ErrClosed.
+type Transport interface {
+	// Publish sends a message to its topic
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe returns the channel where the messages of the topic are delivered to the group,
+	// until ctx is done
+	Subscribe(ctx context.Context, topic, group string) (<-chan Delivery, error)
+	// Close stops the transport, closing its subscriptions
+	Close() error
+}
@@ -0,0 +1,208 @@
+// Package transporttest provides the conformance suite of the transport.Transport contract.
+// Adapters for brokers run it from their tests:
+//
+//	func TestConformance(t *testing.T) {
+//		transporttest.Run(t, func(t *testing.T) transport.Transport {
+//			return newBrokerTransport(t)
+//		})
+//	}
+package transporttest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/theskyinflames/cqrs-eda/pkg/transport"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Timeout is how long the suite waits for a message to be delivered
+var Timeout = 5 * time.Second
+
+// quiet is how long the suite waits to check that a message is not delivered
+var quiet = 100 * time.Millisecond
+
+// Run runs the conformance suite. newTransport must return a new transport, with no messages,
+// for each test. The suite closes it.
+func Run(t *testing.T, newTransport func(t *testing.T) transport.Transport) {
+	t.Run(`Given a subscription, when a message is published to its topic, then it's delivered preserving its fields`, func(t *testing.T) {
+		tr := newTransport(t)
+		defer tr.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := tr.Subscribe(ctx, topic(t), "group")
+		require.NoError(t, err)
+		other, err := tr.Subscribe(ctx, topic(t)+".other", "group")
+		require.NoError(t, err)
+
+		msg := transport.Message{
+			ID:      uuid.New(),
+			Topic:   topic(t),
+			Payload: []byte(`{"name":"alice"}`),
+			Headers: map[string]string{"correlation-id": uuid.NewString()},
+		}
+		require.NoError(t, tr.Publish(ctx, msg))
+
+		dl := receive(t, ch)
+		require.Equal(t, msg, dl.Message())
+		require.NoError(t, dl.Ack(ctx))
+		nothing(t, ch)
+		nothing(t, other)
+	})
+
+	t.Run(`Given several groups, when messages are published, then each group receives all of them, once`, func(t *testing.T) {
+		tr := newTransport(t)
+		defer tr.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first, err := tr.Subscribe(ctx, topic(t), "first")
+		require.NoError(t, err)
+		secondA, err := tr.Subscribe(ctx, topic(t), "second")
+		require.NoError(t, err)
+		secondB, err := tr.Subscribe(ctx, topic(t), "second")
+		require.NoError(t, err)
+
+		const n = 10
+		for i := 0; i < n; i++ {
+			require.NoError(t, tr.Publish(ctx, message(t, i)))
+		}
+
+		received := make(map[string]int)
+		for i := 0; i < n; i++ {
+			dl := receive(t, first)
+			received[string(dl.Message().Payload)]++
+			require.NoError(t, dl.Ack(ctx))
+		}
+		require.Len(t, received, n)
+
+		received = make(map[string]int)
+		for i := 0; i < n; i++ {
+			var dl transport.Delivery
+			select {
+			case dl = <-secondA:
+			case dl = <-secondB:
+			case <-time.After(Timeout):
+				t.Fatal("the message has not been delivered")
+			}
+			received[string(dl.Message().Payload)]++
+			require.NoError(t, dl.Ack(ctx))
+		}
+		require.Len(t, received, n)
+		nothing(t, first)
+		nothing(t, secondA)
+		nothing(t, secondB)
+	})
+
+	t.Run(`Given a delivery, when it's nacked with requeue, then it's delivered again, and otherwise it's not`, func(t *testing.T) {
+		tr := newTransport(t)
+		defer tr.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := tr.Subscribe(ctx, topic(t), "group")
+		require.NoError(t, err)
+		msg := message(t, 0)
+		require.NoError(t, tr.Publish(ctx, msg))
+
+		require.NoError(t, receive(t, ch).Nack(ctx, true))
+		dl := receive(t, ch)
+		require.Equal(t, msg.ID, dl.Message().ID)
+		require.NoError(t, dl.Nack(ctx, false))
+		nothing(t, ch)
+	})
+
+	t.Run(`Given an un-acked delivery, when its subscription ends, then it's delivered again to its group`, func(t *testing.T) {
+		tr := newTransport(t)
+		defer tr.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		firstCtx, cancelFirst := context.WithCancel(ctx)
+
+		first, err := tr.Subscribe(firstCtx, topic(t), "group")
+		require.NoError(t, err)
+		msg := message(t, 0)
+		require.NoError(t, tr.Publish(ctx, msg))
+		require.Equal(t, msg.ID, receive(t, first).Message().ID)
+		cancelFirst()
+		closed(t, first)
+
+		second, err := tr.Subscribe(ctx, topic(t), "group")
+		require.NoError(t, err)
+		dl := receive(t, second)
+		require.Equal(t, msg.ID, dl.Message().ID)
+		require.NoError(t, dl.Ack(ctx))
+		nothing(t, second)
+	})
+
+	t.Run(`Given a subscription, when its context is done, then its channel is closed`, func(t *testing.T) {
+		tr := newTransport(t)
+		defer tr.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		ch, err := tr.Subscribe(ctx, topic(t), "group")
+		require.NoError(t, err)
+		cancel()
+		closed(t, ch)
+	})
+
+	t.Run(`Given a closed transport, when it's used, then it fails and its subscriptions are closed`, func(t *testing.T) {
+		tr := newTransport(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := tr.Subscribe(ctx, topic(t), "group")
+		require.NoError(t, err)
+		require.NoError(t, tr.Close())
+		closed(t, ch)
+
+		require.ErrorIs(t, tr.Publish(ctx, message(t, 0)), transport.ErrClosed)
+		_, err = tr.Subscribe(ctx, topic(t), "group")
+		require.ErrorIs(t, err, transport.ErrClosed)
+	})
+}
+
+// topic returns a topic for the test, so the tests don't interfere if the transport is shared
+func topic(t *testing.T) string {
+	return "transporttest." + uuid.NewSHA1(uuid.Nil, []byte(t.Name())).String()
+}
+
+func message(t *testing.T, i int) transport.Message {
+	return transport.Message{ID: uuid.New(), Topic: topic(t), Payload: []byte(fmt.Sprintf("message %d", i))}
+}
+
+func receive(t *testing.T, ch <-chan transport.Delivery) transport.Delivery {
+	t.Helper()
+	select {
+	case dl, ok := <-ch:
+		require.True(t, ok, "the subscription has been closed")
+		return dl
+	case <-time.After(Timeout):
+		t.Fatal("the message has not been delivered")
+	}
+	return nil
+}
+
+func nothing(t *testing.T, ch <-chan transport.Delivery) {
+	t.Helper()
+	select {
+	case dl := <-ch:
+		t.Fatalf("unexpected message %s", dl.Message().ID)
+	case <-time.After(quiet):
+	}
+}
+
+func closed(t *testing.T, ch <-chan transport.Delivery) {
+	t.Helper()
+	select {
+	case _, ok := <-ch:
+		require.False(t, ok, "unexpected message")
+	case <-time.After(Timeout):
+		t.Fatal("the subscription has not been closed")
+	}
+}